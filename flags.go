@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jiyeol-lee/voca/pkg/vocabulary"
+)
+
+// listFlag collects a flag that may be repeated or given as a comma-separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// addFilterFlags registers the flags that narrow down the words a command works on.
+func addFilterFlags(fs *flag.FlagSet, filter *vocabulary.Filter) {
+	fs.Var((*listFlag)(&filter.Tags), "tag", "only words with this tag (repeatable or comma-separated)")
+	fs.StringVar(&filter.Deck, "deck", "", "only words in this deck")
+}
+
+// dateFlag parses a YYYY-MM-DD date in local time, shifted by days.
+type dateFlag struct {
+	t    *time.Time
+	days int
+}
+
+func (d dateFlag) String() string {
+	if d.t == nil || d.t.IsZero() {
+		return ""
+	}
+	return d.t.AddDate(0, 0, -d.days).Format("2006-01-02")
+}
+
+func (d dateFlag) Set(value string) error {
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return fmt.Errorf("expected a date like 2006-01-02: %w", err)
+	}
+	*d.t = t.AddDate(0, 0, d.days)
+	return nil
+}
+
+// addCreatedFilterFlags registers the flags that narrow down words by the day they were added.
+func addCreatedFilterFlags(fs *flag.FlagSet, filter *vocabulary.Filter) {
+	fs.Var(dateFlag{t: &filter.CreatedSince}, "since", "only words added on or after this date (YYYY-MM-DD)")
+	// the until date is inclusive, so the filter excludes words from the next day on
+	fs.Var(dateFlag{t: &filter.CreatedBefore, days: 1}, "until", "only words added on or before this date (YYYY-MM-DD)")
+}
@@ -1,23 +1,38 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/glamour"
 	"golang.org/x/sys/unix"
 
 	"github.com/jiyeol-lee/openai"
+	"github.com/jiyeol-lee/voca/pkg/config"
+	"github.com/jiyeol-lee/voca/pkg/kindle"
 	"github.com/jiyeol-lee/voca/pkg/news"
 	"github.com/jiyeol-lee/voca/pkg/vocabulary"
 )
 
 type VocaGpt struct {
-	model           string
+	model string
+	// systemContent is a template where {target} and {explanation} are replaced with the languages
+	// of the configured language pair, and {TARGET} and {EXPLANATION} with their uppercase forms.
 	systemContent   string
 	temperature     float32
 	reasoningEffort string
@@ -33,42 +48,43 @@ You are a precise bilingual vocabulary tutor. Obey every directive below; never
 	- Write in Markdown only.
 	- Never ask follow-up questions or add commentary outside the schema.
 	- Every placeholder wrapped in square brackets must be replaced with real content, and the brackets must be removed.
-	- Use backticks only inside the English example sentences; never use backticks in Korean sections.
-	- Ensure Korean sections are written purely in Korean (Hangul) with no English words unless the original term must stay in English.
+	- Use backticks only inside the {target} example sentences; never use backticks in {explanation} sections.
+	- Ensure {explanation} sections are written purely in {explanation} with no {target} words unless the original term must stay in {target}.
 	- If information is ambiguous, infer the most reasonable option instead of noting uncertainty.
+	- If a context sentence is provided after the word or phrase, explain the sense used in that context and keep every example in that sense; treat the source and note as hints only.
 
 Workflow:
 1. Read the provided text carefully.
-2. Identify the exact word or phrase that needs explanation.
-3. Give a concise English explanation.
-	4. Provide five English example sentences that each include the word or phrase, wrapping the target expression in backticks (English only).
-	5. Translate the explanation and each example sentence into Korean, using purely Korean wording.
+2. Identify the exact word or phrase that needs explanation (the first line of the text).
+3. Give a concise {target} explanation.
+	4. Provide five {target} example sentences that each include the word or phrase, wrapping the target expression in backticks ({target} only).
+	5. Translate the explanation and each example sentence into {explanation}, using purely {explanation} wording.
 
 Output Schema (use exactly this structure):
 # [WORD_OR_PHRASE]
 
 ## Pronunciation
-[PRONUNCIATION_OF_THE_WORD_OR_PHRASE_IN_ENGLISH]
-
-## Explanation (English)
-[BRIEF_EXPLANATION_OF_THE_WORD_OR_PHRASE_IN_ENGLISH]
-
-### Examples (English)
-1. [EXAMPLE_SENTENCE_1_IN_ENGLISH]
-2. [EXAMPLE_SENTENCE_2_IN_ENGLISH]
-3. [EXAMPLE_SENTENCE_3_IN_ENGLISH]
-4. [EXAMPLE_SENTENCE_4_IN_ENGLISH]
-5. [EXAMPLE_SENTENCE_5_IN_ENGLISH]
-
-## Explanation (Korean)
-[TRANSLATION_OF_BRIEF_EXPLANATION_IN_KOREAN]
-
-### Examples (Korean)
-1. [TRANSLATION_OF_EXAMPLE_SENTENCE_1_IN_KOREAN]
-2. [TRANSLATION_OF_EXAMPLE_SENTENCE_2_IN_KOREAN]
-3. [TRANSLATION_OF_EXAMPLE_SENTENCE_3_IN_KOREAN]
-4. [TRANSLATION_OF_EXAMPLE_SENTENCE_4_IN_KOREAN]
-5. [TRANSLATION_OF_EXAMPLE_SENTENCE_5_IN_KOREAN]`,
+[PRONUNCIATION_OF_THE_WORD_OR_PHRASE_IN_{TARGET}]
+
+## Explanation ({target})
+[BRIEF_EXPLANATION_OF_THE_WORD_OR_PHRASE_IN_{TARGET}]
+
+### Examples ({target})
+1. [EXAMPLE_SENTENCE_1_IN_{TARGET}]
+2. [EXAMPLE_SENTENCE_2_IN_{TARGET}]
+3. [EXAMPLE_SENTENCE_3_IN_{TARGET}]
+4. [EXAMPLE_SENTENCE_4_IN_{TARGET}]
+5. [EXAMPLE_SENTENCE_5_IN_{TARGET}]
+
+## Explanation ({explanation})
+[TRANSLATION_OF_BRIEF_EXPLANATION_IN_{EXPLANATION}]
+
+### Examples ({explanation})
+1. [TRANSLATION_OF_EXAMPLE_SENTENCE_1_IN_{EXPLANATION}]
+2. [TRANSLATION_OF_EXAMPLE_SENTENCE_2_IN_{EXPLANATION}]
+3. [TRANSLATION_OF_EXAMPLE_SENTENCE_3_IN_{EXPLANATION}]
+4. [TRANSLATION_OF_EXAMPLE_SENTENCE_4_IN_{EXPLANATION}]
+5. [TRANSLATION_OF_EXAMPLE_SENTENCE_5_IN_{EXPLANATION}]`,
 	temperature:     1,
 	reasoningEffort: "low",
 }
@@ -82,41 +98,82 @@ You are a bilingual storytelling assistant. Follow every directive exactly; do n
 	- Responses must stay impersonal, use true line breaks, and be formatted in Markdown.
 	- Never ask follow-up questions or add commentary outside the schema.
 	- Replace every placeholder inside square brackets with real content, then remove the brackets.
-	- Use backticks only in the English story when showing supplied words; never place backticks in the Korean section.
-	- Ensure the Korean title, word list translations, and story are written entirely in Korean (Hangul) without English words unless the supplied vocabulary requires it.
+	- Use backticks only in the {target} story when showing supplied words; never place backticks in the {explanation} section.
+	- Ensure the {explanation} title, word list translations, and story are written entirely in {explanation} without {target} words unless the supplied vocabulary requires it.
 
 Workflow:
-1. Create a vivid, catchy story title in English and provide a faithful Korean title in parentheses on the same line.
-2. List every supplied vocabulary word as a bullet that shows the English word and its Korean translation.
-	3. Write an engaging English story that uses each provided word at least once, wrapping the word itself in backticks (English only).
-	4. Translate the entire story into Korean, ensuring the translation uses only Korean wording.
+1. Create a vivid, catchy story title in {target} and provide a faithful {explanation} title in parentheses on the same line.
+2. List every supplied vocabulary word as a bullet that shows the {target} word and its {explanation} translation.
+	3. Write an engaging {target} story that uses each provided word at least once, wrapping the word itself in backticks ({target} only).
+	4. Translate the entire story into {explanation}, ensuring the translation uses only {explanation} wording.
 
 Output Schema (must match exactly):
-# [STORY_TITLE_IN_ENGLISH] (STORY_TITLE_IN_KOREAN)
+# [STORY_TITLE_IN_{TARGET}] (STORY_TITLE_IN_{EXPLANATION})
 
 ## Selected Words
 
-- [ENGLISH_WORD_1] (ENGLISH_WORD_1_IN_KOREAN)
-- [ENGLISH_WORD_2] (ENGLISH_WORD_2_IN_KOREAN)
+- [{TARGET}_WORD_1] ({TARGET}_WORD_1_IN_{EXPLANATION})
+- [{TARGET}_WORD_2] ({TARGET}_WORD_2_IN_{EXPLANATION})
 - ...
 
-## Story (English)
+## Story ({target})
 
-[STORY_IN_ENGLISH]
+[STORY_IN_{TARGET}]
 
-## Story (Korean)
+## Story ({explanation})
 
-[TRANSLATION_OF_STORY_IN_KOREAN]`,
+[TRANSLATION_OF_STORY_IN_{EXPLANATION}]`,
 	temperature:     1,
 	reasoningEffort: "low",
 }
 
+// systemPrompt fills the language placeholders of the system content with the language pair.
+func (g VocaGpt) systemPrompt(pair config.LanguagePair) string {
+	upper := func(language string) string {
+		return strings.Join(strings.Fields(strings.ToUpper(language)), "_")
+	}
+	return strings.NewReplacer(
+		"{target}", pair.TargetLanguage,
+		"{TARGET}", upper(pair.TargetLanguage),
+		"{explanation}", pair.ExplanationLanguage,
+		"{EXPLANATION}", upper(pair.ExplanationLanguage),
+	).Replace(g.systemContent)
+}
+
+var subcommandsUsage = "Expected 'news', 'add', 'delete', 'edit', 'merge', 'dedupe', 'master', 'archive', 'activate', 'sync', 'doctor', 'undo', 'history', 'stats', 'list', 'tag', 'tags', 'import', 'export', 'story', 'quiz' or 'study' subcommands"
+
 func main() {
+	var flagConfig config.Config
+	flag.StringVar(&flagConfig.RemoteURL, "remote", "", "git remote URL of the vocabulary store")
+	flag.StringVar(&flagConfig.Branch, "branch", "", "branch of the vocabulary store")
+	flag.StringVar(&flagConfig.LocalPath, "path", "", "local directory of the vocabulary store")
+	flag.StringVar(&flagConfig.TableName, "table", "", "table name of the vocabulary")
+	flag.StringVar(&flagConfig.StorageMode, "storage", "", "storage mode of the vocabulary store: git or local")
+	flag.StringVar(&flagConfig.Backend, "backend", "", "backend of the vocabulary store: csv, sqlite or json")
+	flag.StringVar(&flagConfig.Profile, "profile", "", "language profile defined in the config file")
+	flag.StringVar(&flagConfig.TargetLanguage, "target", "", "language of the words, e.g. German")
+	flag.StringVar(&flagConfig.ExplanationLanguage, "explain", "", "language of the explanations, e.g. Spanish")
 	flag.Parse()
 	args := flag.Args()
 
+	cfg, err := config.Load(flagConfig.Profile)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+	// flags win over the config file, the profile and the environment
+	cfg.Override(flagConfig)
+	languages := cfg.LanguagePair()
+	storeOpts := vocabulary.StoreOptions{
+		RemoteURL:   cfg.RemoteURL,
+		Branch:      cfg.Branch,
+		LocalPath:   cfg.LocalPath,
+		TableName:   cfg.VocabularyTableName(),
+		StorageMode: vocabulary.StorageMode(cfg.StorageMode),
+		Backend:     vocabulary.BackendKind(cfg.Backend),
+	}
+
 	if len(args) < 1 {
-		fmt.Println("Expected 'news', 'add', 'delete', 'story' or 'study' subcommands")
+		fmt.Println(subcommandsUsage)
 		os.Exit(1)
 	}
 
@@ -161,38 +218,371 @@ func main() {
 		}
 
 	case "add":
-		content := strings.Join(args[1:], " ")
+		addFlags := flag.NewFlagSet("add", flag.ExitOnError)
+		var addOpts vocabulary.AddOptions
+		addFlags.StringVar(&addOpts.Context, "context", "", "sentence the word appeared in")
+		addFlags.StringVar(&addOpts.Source, "source", "", "source URL or title where the word was found")
+		addFlags.StringVar(&addOpts.Note, "note", "", "free-form note")
+		addFlags.Var((*listFlag)(&addOpts.Tags), "tag", "tag of the word (repeatable or comma-separated)")
+		addFlags.StringVar(&addOpts.Deck, "deck", "", "deck of the word")
+		addFlags.BoolVar(&addOpts.Force, "force", false, "add even when another form of the word exists")
+		file := addFlags.String("f", "", "add every line of the file (word, optionally followed by a tab and its context)")
+		addFlags.Parse(args[1:])
+		content := strings.Join(addFlags.Args(), " ")
+
+		s := vocabulary.NewStore(storeOpts)
+
+		if *file == "" && content != "-" {
+			begin(s)
+			_, err := s.AddVocabulary(content, addOpts)
+			if err != nil {
+				abort(s, "Error adding vocabulary: %v", err)
+			}
+			commit(s)
+			break
+		}
+		if *file != "" && content != "" {
+			log.Fatalf("Expected either -f <file>, '-' or a word")
+		}
 
-		s := vocabulary.NewStore()
+		r := os.Stdin
+		if *file != "" {
+			f, err := os.Open(*file)
+			if err != nil {
+				log.Fatalf("Error opening file: %v", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		entries, err := vocabulary.ReadVocabularyEntries(r, addOpts)
+		if err != nil {
+			log.Fatalf("Error reading entries: %v", err)
+		}
+		if len(entries) == 0 {
+			log.Fatalf("No entries to add")
+		}
 
-		_, err := s.AddVocabulary(content)
+		begin(s)
+		added, skipped, err := s.AddVocabularies(entries)
 		if err != nil {
-			log.Fatalf("Error adding vocabulary: %v", err)
+			abort(s, "Error adding vocabulary: %v", err)
 		}
+		commit(s)
+		for _, word := range skipped {
+			fmt.Printf("Skipped duplicate: %s\n", word)
+		}
+		fmt.Printf("Added %d word(s), skipped %d duplicate(s)\n", len(added), len(skipped))
 
 	case "delete":
 		content := strings.Join(args[1:], " ")
 
-		s := vocabulary.NewStore()
+		s := vocabulary.NewStore(storeOpts)
 
+		begin(s)
 		err := s.DeleteVocabulary(content)
 		if err != nil {
-			log.Fatalf("Error deleting vocabulary: %v", err)
+			abort(s, "Error deleting vocabulary: %v", err)
+		}
+		commit(s)
+
+	case "edit":
+		if len(args) != 3 {
+			log.Fatalf("Expected 'edit <old> <new>' (quote phrases)")
+		}
+
+		s := vocabulary.NewStore(storeOpts)
+
+		begin(s)
+		rec, err := s.RenameVocabulary(args[1], args[2])
+		if err != nil {
+			abort(s, "Error editing vocabulary: %v", err)
+		}
+		commit(s)
+		fmt.Printf("Renamed %q to %q\n", args[1], rec["word"])
+
+	case "merge":
+		if len(args) != 3 {
+			log.Fatalf("Expected 'merge <into> <from>' (quote phrases)")
+		}
+
+		s := vocabulary.NewStore(storeOpts)
+
+		begin(s)
+		rec, err := s.MergeVocabulary(args[1], args[2])
+		if err != nil {
+			abort(s, "Error merging vocabulary: %v", err)
+		}
+		commit(s)
+		fmt.Printf("Merged %q into %q (read %s times)\n", args[2], rec["word"], rec["read_count"])
+
+	case "dedupe":
+		s := vocabulary.NewStore(storeOpts)
+
+		err := s.ListLemmaClusters()
+		if err != nil {
+			log.Fatalf("Error finding duplicate lemmas: %v", err)
+		}
+
+	case "master", "archive", "activate":
+		content := strings.Join(args[1:], " ")
+		status := map[string]vocabulary.Status{
+			"master":   vocabulary.StatusMastered,
+			"archive":  vocabulary.StatusArchived,
+			"activate": vocabulary.StatusActive,
+		}[args[0]]
+
+		s := vocabulary.NewStore(storeOpts)
+
+		begin(s)
+		rec, err := s.SetStatus(content, status)
+		if err != nil {
+			abort(s, "Error updating vocabulary status: %v", err)
+		}
+		commit(s)
+		fmt.Printf("%q is now %s\n", rec["word"], rec["status"])
+
+	case "sync":
+		s := vocabulary.NewStore(storeOpts)
+
+		pushed, err := s.Sync()
+		if err != nil {
+			log.Fatalf("Error syncing store: %v", err)
+		}
+		if pushed == 0 {
+			fmt.Println("Store is up to date")
+		} else {
+			fmt.Printf("Pushed %d local commit(s)\n", pushed)
+		}
+
+	case "doctor":
+		doctorFlags := flag.NewFlagSet("doctor", flag.ExitOnError)
+		fix := doctorFlags.Bool("fix", false, "repair the problems found in one commit")
+		doctorFlags.Parse(args[1:])
+
+		s := vocabulary.NewStore(storeOpts)
+
+		begin(s)
+		found, fixed, err := s.Doctor(*fix)
+		if err != nil {
+			abort(s, "Error checking store: %v", err)
+		}
+		commit(s)
+		switch {
+		case found == 0:
+			fmt.Println("No problems found")
+		case !*fix:
+			fmt.Printf("Found %d problem(s), run 'voca doctor -fix' to repair them\n", found)
+		default:
+			fmt.Printf("Fixed %d of %d problem(s)\n", fixed, found)
+		}
+
+	case "undo":
+		s := vocabulary.NewStore(storeOpts)
+
+		summary, err := s.Undo()
+		if err != nil {
+			log.Fatalf("Error undoing last operation: %v", err)
+		}
+		fmt.Printf("Undid: %s\n", summary)
+
+	case "history":
+		historyFlags := flag.NewFlagSet("history", flag.ExitOnError)
+		limit := historyFlags.Int("n", 20, "number of operations to show")
+		historyFlags.Parse(args[1:])
+
+		s := vocabulary.NewStore(storeOpts)
+
+		err := s.ListHistory(*limit)
+		if err != nil {
+			log.Fatalf("Error listing history: %v", err)
+		}
+
+	case "stats":
+		s := vocabulary.NewStore(storeOpts)
+
+		err := s.PrintStats()
+		if err != nil {
+			log.Fatalf("Error printing stats: %v", err)
+		}
+
+	case "list":
+		listFlags := flag.NewFlagSet("list", flag.ExitOnError)
+		var q vocabulary.ListQuery
+		addFilterFlags(listFlags, &q.Filter)
+		addCreatedFilterFlags(listFlags, &q.Filter)
+		listFlags.StringVar(&q.Contains, "q", "", "only words containing this text")
+		listFlags.Func("status", "only words with this status: active, mastered or archived", func(value string) error {
+			status, err := vocabulary.ParseStatus(value)
+			q.Status = status
+			return err
+		})
+		pattern := listFlags.String("regex", "", "only words matching this regular expression")
+		listFlags.Func("min-read", "only words read at least this many times", func(value string) error {
+			n, err := strconv.Atoi(value)
+			q.MinReadCount = &n
+			return err
+		})
+		listFlags.Func("max-read", "only words read at most this many times", func(value string) error {
+			n, err := strconv.Atoi(value)
+			q.MaxReadCount = &n
+			return err
+		})
+		listFlags.StringVar(&q.SortBy, "sort", "created_at", "column to sort by, e.g. word, read_count, due_at")
+		listFlags.BoolVar(&q.Descending, "desc", false, "sort in descending order")
+		listFlags.IntVar(&q.Page, "page", 1, "page to show")
+		listFlags.IntVar(&q.PerPage, "per-page", 20, "words per page, 0 for all")
+		listFlags.Parse(args[1:])
+		if *pattern != "" {
+			re, err := regexp.Compile(*pattern)
+			if err != nil {
+				log.Fatalf("Error compiling regex: %v", err)
+			}
+			q.Pattern = re
+		}
+
+		s := vocabulary.NewStore(storeOpts)
+
+		err := s.ListVocabulary(q)
+		if err != nil {
+			log.Fatalf("Error listing vocabulary: %v", err)
+		}
+
+	case "tag":
+		tagFlags := flag.NewFlagSet("tag", flag.ExitOnError)
+		var edit vocabulary.TagEdit
+		tagFlags.Var((*listFlag)(&edit.Add), "add", "tag to add (repeatable or comma-separated)")
+		tagFlags.Var((*listFlag)(&edit.Remove), "remove", "tag to remove (repeatable or comma-separated)")
+		deck := tagFlags.String("deck", "", "move the word to this deck")
+		noDeck := tagFlags.Bool("no-deck", false, "remove the word from its deck")
+		tagFlags.Parse(args[1:])
+		content := strings.Join(tagFlags.Args(), " ")
+		if *deck != "" || *noDeck {
+			edit.Deck = deck
+		}
+
+		s := vocabulary.NewStore(storeOpts)
+
+		begin(s)
+		rec, err := s.EditTags(content, edit)
+		if err != nil {
+			abort(s, "Error editing tags: %v", err)
+		}
+		commit(s)
+		fmt.Printf("%s\ttags: %s\tdeck: %s\n", rec["word"], rec["tags"], rec["deck"])
+
+	case "tags":
+		s := vocabulary.NewStore(storeOpts)
+
+		err := s.ListTags()
+		if err != nil {
+			log.Fatalf("Error listing tags: %v", err)
+		}
+
+	case "import":
+		if len(args) < 2 || args[1] != "kindle" {
+			log.Fatalf("Expected 'import kindle'")
+		}
+		importFlags := flag.NewFlagSet("import kindle", flag.ExitOnError)
+		vocabPath := importFlags.String("vocab", "", "path to the Vocabulary Builder database (vocab.db)")
+		clippingsPath := importFlags.String("clippings", "", "path to My Clippings.txt")
+		language := importFlags.String("lang", "", "only import Vocabulary Builder words of this language, e.g. en")
+		maxWords := importFlags.Int("max-words", 3, "longest highlight in words imported from My Clippings.txt")
+		var importOpts vocabulary.AddOptions
+		importFlags.Var((*listFlag)(&importOpts.Tags), "tag", "tag of the imported words (repeatable or comma-separated)")
+		importFlags.StringVar(&importOpts.Deck, "deck", "", "deck of the imported words")
+		importFlags.BoolVar(&importOpts.Force, "force", false, "import words even when another form of them exists")
+		importFlags.Parse(args[2:])
+		if *vocabPath == "" && *clippingsPath == "" {
+			log.Fatalf("Expected -vocab and/or -clippings")
+		}
+
+		lookups := make([]kindle.Lookup, 0)
+		if *vocabPath != "" {
+			l, err := kindle.ReadVocabularyBuilder(*vocabPath, *language)
+			if err != nil {
+				log.Fatalf("Error reading vocabulary builder: %v", err)
+			}
+			lookups = append(lookups, l...)
+		}
+		if *clippingsPath != "" {
+			l, err := kindle.ReadClippings(*clippingsPath, *maxWords)
+			if err != nil {
+				log.Fatalf("Error reading clippings: %v", err)
+			}
+			lookups = append(lookups, l...)
+		}
+
+		entries := make([]vocabulary.VocabularyEntry, 0, len(lookups))
+		for _, l := range lookups {
+			opts := importOpts
+			opts.Context = l.Usage
+			opts.Source = l.Book
+			entries = append(entries, vocabulary.VocabularyEntry{Word: l.Word, AddOptions: opts})
+		}
+
+		s := vocabulary.NewStore(storeOpts)
+
+		begin(s)
+		added, skipped, err := s.AddVocabularies(entries)
+		if err != nil {
+			abort(s, "Error importing vocabulary: %v", err)
+		}
+		commit(s)
+		fmt.Printf("Imported %d word(s), skipped %d duplicate(s)\n", len(added), len(skipped))
+
+	case "export":
+		exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
+		formatName := exportFlags.String("format", "json", "export format: anki, quizlet, json or md")
+		output := exportFlags.String("o", "", "output file (default: stdout)")
+		var filter vocabulary.Filter
+		addFilterFlags(exportFlags, &filter)
+		addCreatedFilterFlags(exportFlags, &filter)
+		exportFlags.Parse(args[1:])
+
+		format, err := vocabulary.ParseExportFormat(*formatName)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		w := os.Stdout
+		if *output != "" {
+			file, err := os.Create(*output)
+			if err != nil {
+				log.Fatalf("Error creating output file: %v", err)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		s := vocabulary.NewStore(storeOpts)
+
+		err = s.ExportVocabulary(w, format, filter)
+		if err != nil {
+			log.Fatalf("Error exporting vocabulary: %v", err)
 		}
 
 	case "story":
+		storyFlags := flag.NewFlagSet("story", flag.ExitOnError)
+		var filter vocabulary.Filter
+		addFilterFlags(storyFlags, &filter)
+		storyFlags.Parse(args[1:])
+
 		apiKey := mustGetAPIKey()
-		s := vocabulary.NewStore()
-		words, err := s.GetRandomWords(10)
+		s := vocabulary.NewStore(storeOpts)
+		records, err := s.GetRandomWords(10, filter)
 		if err != nil {
 			log.Fatalf("Error getting random words: %v", err)
 		}
+		words := make([]string, 0, len(records))
+		for _, record := range records {
+			words = append(words, record["word"])
+		}
 
 		client := openai.NewClient(apiKey)
 		req := openai.ChatCompletionRequest{
 			Model: vocaStoryGpt.model,
 			Messages: []openai.Message{
-				{Role: "system", Content: vocaStoryGpt.systemContent},
+				{Role: "system", Content: vocaStoryGpt.systemPrompt(languages)},
 				{Role: "user", Content: strings.Join(words, ", ")},
 			},
 			Temperature:     vocaStoryGpt.temperature,
@@ -206,49 +596,324 @@ func main() {
 			log.Fatalf("stream error: %v", err)
 		}
 
+		// the words only count as read once the story was told
+		begin(s)
+		err = s.RecordStory(records)
+		if err != nil {
+			abort(s, "Error recording story: %v", err)
+		}
+		commit(s)
+
+	case "quiz":
+		quizFlags := flag.NewFlagSet("quiz", flag.ExitOnError)
+		var filter vocabulary.Filter
+		addFilterFlags(quizFlags, &filter)
+		count := quizFlags.Int("n", 10, "number of questions")
+		quizFlags.Parse(args[1:])
+
+		s := vocabulary.NewStore(storeOpts)
+		questions, err := s.QuizQuestions(*count, filter)
+		if err != nil {
+			log.Fatalf("Error getting quiz questions: %v", err)
+		}
+		if len(questions) == 0 {
+			fmt.Println("No words with a context to quiz on.")
+			return
+		}
+
+		// the answers are recorded once the quiz is over, so the store is not locked while answering
+		answers := make([]vocabulary.QuizAnswer, 0, len(questions))
+		input := bufio.NewScanner(os.Stdin)
+		for i, question := range questions {
+			fmt.Printf("%d/%d  %s\n> ", i+1, len(questions), question.Cloze)
+			if !input.Scan() {
+				fmt.Println()
+				break
+			}
+			correct := question.Check(input.Text())
+			if correct {
+				fmt.Println("Correct!")
+			} else {
+				fmt.Printf("Wrong, it was %q.\n", question.Word)
+			}
+			answers = append(answers, vocabulary.QuizAnswer{WordID: question.WordID, Correct: correct, AnsweredAt: time.Now()})
+		}
+		if len(answers) == 0 {
+			return
+		}
+
+		begin(s)
+		err = s.RecordQuizAnswers(answers)
+		if err != nil {
+			abort(s, "Error recording quiz answers: %v", err)
+		}
+		commit(s)
+
+		correct := 0
+		for _, answer := range answers {
+			if answer.Correct {
+				correct++
+			}
+		}
+		fmt.Printf("Score: %d/%d\n", correct, len(answers))
+
 	case "study":
-		apiKey := mustGetAPIKey()
-		s := vocabulary.NewStore()
+		studyFlags := flag.NewFlagSet("study", flag.ExitOnError)
+		var filter vocabulary.Filter
+		addFilterFlags(studyFlags, &filter)
+		refresh := studyFlags.Bool("refresh", false, "ask for a new explanation instead of the cached one")
+		studyFlags.Parse(args[1:])
+
+		s := vocabulary.NewStore(storeOpts)
+		begin(s)
 
-		isUserEntered := len(args) > 1
+		isUserEntered := studyFlags.NArg() > 0
 		var content string
+		var vocabularyID string
 		if isUserEntered {
-			content = strings.Join(args[1:], " ")
+			content = strings.Join(studyFlags.Args(), " ")
+			// a word already in the store is studied with its context and graded as well
+			rec, err := s.FindVocabulary(content)
+			if err != nil {
+				abort(s, "Error finding vocabulary: %v", err)
+			}
+			if rec != nil {
+				content = studyMessage(rec)
+				vocabularyID = rec["id"]
+			}
 		} else {
-			rec, err := s.GetLeastReadVocabulary()
+			rec, err := s.GetDueVocabulary(filter)
 			if err != nil {
-				log.Fatalf("Error getting least read vocabulary: %v", err)
+				abort(s, "Error getting due vocabulary: %v", err)
 			}
-			if w, ok := rec["word"]; ok {
-				content = w
-			} else {
-				log.Fatalf("Error: 'word' not found in vocabulary record")
+			if _, ok := rec["word"]; !ok {
+				abort(s, "Error: 'word' not found in vocabulary record")
 			}
+			content = studyMessage(rec)
+			vocabularyID = rec["id"]
 		}
 
-		client := openai.NewClient(apiKey)
 		req := openai.ChatCompletionRequest{
 			Model: vocaStudyGpt.model,
 			Messages: []openai.Message{
-				{Role: "system", Content: vocaStudyGpt.systemContent},
+				{Role: "system", Content: vocaStudyGpt.systemPrompt(languages)},
 				{Role: "user", Content: content},
 			},
 			Temperature:     vocaStudyGpt.temperature,
 			ReasoningEffort: vocaStudyGpt.reasoningEffort,
 		}
-		opts := openai.StreamOptions{
-			WordWrap: 100,
-			Cancel:   func() {},
+
+		// words not in the store have nowhere to cache their explanation, so it is streamed
+		if vocabularyID == "" {
+			client := openai.NewClient(mustGetAPIKey())
+			opts := openai.StreamOptions{
+				WordWrap: 100,
+				Cancel:   func() {},
+			}
+			if err := client.CreateChatCompletionStreamWithMarkdown(context.Background(), req, os.Stdout, opts); err != nil {
+				abort(s, "stream error: %v", err)
+			}
+			commit(s)
+			return
 		}
-		if err := client.CreateChatCompletionStreamWithMarkdown(context.Background(), req, os.Stdout, opts); err != nil {
-			log.Fatalf("stream error: %v", err)
+
+		version := promptVersion(req.Messages)
+		var cached string
+		if !*refresh {
+			explanation, err := s.CachedExplanation(vocabularyID, req.Model, version)
+			if err != nil {
+				abort(s, "Error reading cached explanation: %v", err)
+			}
+			cached = explanation
 		}
+		// a new explanation is only cached along with the grade, so the store is not locked while grading
+		var generated string
+		if cached != "" {
+			if err := printMarkdown(cached); err != nil {
+				abort(s, "Error rendering explanation: %v", err)
+			}
+		} else {
+			explanation, err := streamMarkdown(mustGetAPIKey(), req)
+			if err != nil {
+				abort(s, "stream error: %v", err)
+			}
+			generated = explanation
+		}
+
+		grade, ok := promptGrade()
+		if generated != "" {
+			err := s.SaveExplanation(vocabularyID, req.Model, version, generated)
+			if err != nil {
+				abort(s, "Error caching explanation: %v", err)
+			}
+		}
+		if !ok {
+			commit(s)
+			return
+		}
+		rec, err := s.ReviewVocabulary(vocabularyID, grade)
+		if err != nil {
+			abort(s, "Error reviewing vocabulary: %v", err)
+		}
+		commit(s)
+		fmt.Printf("Next review of %q on %s\n", rec["word"], formatDueDate(rec["due_at"]))
 	default:
-		fmt.Println("Expected 'news', 'add', 'delete', 'story' or 'study' subcommands")
+		fmt.Println(subcommandsUsage)
 		os.Exit(1)
 	}
 }
 
+// studyMessage builds the user message of the study prompt from a vocabulary record,
+// including where the word was seen so the explanation matches that sense.
+func studyMessage(rec map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(rec["word"])
+	if rec["context"] != "" {
+		sb.WriteString("\n\nContext: " + rec["context"])
+	}
+	if rec["source"] != "" {
+		sb.WriteString("\nSource: " + rec["source"])
+	}
+	if rec["note"] != "" {
+		sb.WriteString("\nNote: " + rec["note"])
+	}
+	return sb.String()
+}
+
+// promptVersion identifies the prompt an explanation was made from, so that a cached explanation
+// is only reused while the system prompt, the language pair and the context of the word are unchanged.
+func promptVersion(messages []openai.Message) string {
+	h := sha256.New()
+	for _, m := range messages {
+		fmt.Fprintf(h, "%s\x00%s\x00", m.Role, m.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// teeTransport keeps a copy of every response body as it is read, so that the text of a completion
+// can be cached even though CreateChatCompletionStreamWithMarkdown only writes it rendered.
+type teeTransport struct {
+	base http.RoundTripper
+	body bytes.Buffer
+}
+
+func (t *teeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(resp.Body, &t.body), resp.Body}
+	return resp, nil
+}
+
+// streamedText returns the text of the completion chunks read so far, from their "data: {...}" events.
+func (t *teeTransport) streamedText() (string, error) {
+	var sb strings.Builder
+	for line := range strings.Lines(t.body.String()) {
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk openai.ChatCompletionStreamResponse
+		err := json.Unmarshal([]byte(data), &chunk)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if len(chunk.Choices) > 0 {
+			sb.WriteString(chunk.Choices[0].Delta.Content)
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// streamMarkdown streams the answer of the model rendered as markdown, like the story, and returns its text.
+func streamMarkdown(apiKey string, req openai.ChatCompletionRequest) (string, error) {
+	tee := &teeTransport{base: http.DefaultTransport}
+	// the timeout of the default client of the openai package
+	httpClient := &http.Client{Timeout: 30 * time.Second, Transport: tee}
+	client := openai.NewClient(apiKey, openai.WithHTTPClient(httpClient))
+	opts := openai.StreamOptions{
+		WordWrap: 100,
+		Cancel:   func() {},
+	}
+	err := client.CreateChatCompletionStreamWithMarkdown(context.Background(), req, os.Stdout, opts)
+	if err != nil {
+		return "", err
+	}
+	return tee.streamedText()
+}
+
+func printMarkdown(markdown string) error {
+	r, err := glamour.NewTermRenderer(glamour.WithAutoStyle(), glamour.WithWordWrap(100))
+	if err != nil {
+		return err
+	}
+	out, err := r.Render(markdown)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Print(out)
+	return err
+}
+
+// promptGrade asks how well the word was recalled until a valid grade is entered.
+// It returns false when the user skips grading.
+func promptGrade() (vocabulary.Grade, bool) {
+	for {
+		fmt.Print("How well did you recall it? 0 (blackout) - 5 (perfect), or 's' to skip: ")
+		var input string
+		fmt.Scanln(&input)
+		if input == "s" {
+			return 0, false
+		}
+		grade, err := vocabulary.ParseGrade(input)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		return grade, true
+	}
+}
+
+func formatDueDate(dueAt string) string {
+	t, err := time.Parse(time.RFC3339Nano, dueAt)
+	if err != nil {
+		return dueAt
+	}
+	return t.Format("2006-01-02")
+}
+
+// transaction is the unit of work of a command on the store, so that a command
+// makes a single commit and nothing at all when it fails midway.
+type transaction interface {
+	Begin() error
+	Commit() error
+	Rollback() error
+}
+
+func begin(tx transaction) {
+	if err := tx.Begin(); err != nil {
+		log.Fatalf("Error opening store: %v", err)
+	}
+}
+
+func commit(tx transaction) {
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("Error saving changes: %v", err)
+	}
+}
+
+// abort discards the changes of the command and exits with the error.
+func abort(tx transaction, format string, v ...any) {
+	if err := tx.Rollback(); err != nil {
+		log.Printf("error rolling back changes: %v\n", err)
+	}
+	log.Fatalf(format, v...)
+}
+
 func mustGetAPIKey() string {
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the user settings of voca.
+// Values are resolved in order: defaults, config file, the selected profile, environment variables.
+// Command-line flags are applied on top by the caller.
+type Config struct {
+	RemoteURL string `json:"remote_url"`
+	Branch    string `json:"branch"`
+	LocalPath string `json:"local_path"`
+	TableName string `json:"table_name"`
+	// StorageMode is either "git" to sync the store with RemoteURL or "local" to keep it on disk only.
+	StorageMode string `json:"storage_mode"`
+	// Backend is how the tables are kept in the store: "csv", "sqlite" or "json".
+	Backend string `json:"backend"`
+	// TargetLanguage is the language of the collected words.
+	TargetLanguage string `json:"target_language"`
+	// ExplanationLanguage is the language the words are explained in.
+	ExplanationLanguage string `json:"explanation_language"`
+	// Profile selects one of Profiles.
+	Profile  string             `json:"profile"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Profile is a named language pair, optionally with its own table.
+// When TableName is empty, the table is derived from the target language.
+type Profile struct {
+	LanguagePair
+	TableName string `json:"table_name"`
+}
+
+// Default returns the configuration used when nothing is overridden.
+func Default() Config {
+	return Config{
+		RemoteURL:           "git@github.com:jiyeol-lee/csv__voca.git",
+		Branch:              "",
+		LocalPath:           "",
+		TableName:           "",
+		StorageMode:         "git",
+		Backend:             "csv",
+		TargetLanguage:      "English",
+		ExplanationLanguage: "Korean",
+	}
+}
+
+// Path returns the location of the config file.
+// VOCA_CONFIG takes precedence, then $XDG_CONFIG_HOME/voca/config.json,
+// falling back to ~/.config/voca/config.json.
+func Path() (string, error) {
+	if path := os.Getenv("VOCA_CONFIG"); path != "" {
+		return path, nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory: %w", err)
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "voca", "config.json"), nil
+}
+
+// Load reads the config file if it exists, applies the selected profile and then the environment variable overrides.
+// The profile is the one given, e.g. by a command-line flag, or else VOCA_PROFILE or the profile of the config file.
+func Load(profile string) (Config, error) {
+	c := Default()
+
+	path, err := Path()
+	if err != nil {
+		return c, err
+	}
+	err = c.readFile(path)
+	if err != nil {
+		return c, err
+	}
+	c.Override(Config{Profile: os.Getenv("VOCA_PROFILE")})
+	c.Override(Config{Profile: profile})
+	err = c.ApplyProfile()
+	if err != nil {
+		return c, err
+	}
+	c.applyEnv()
+
+	return c, nil
+}
+
+// readFile merges the non-empty values of the JSON file at path into the config.
+// A missing file is not an error.
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	var fc Config
+	err = json.Unmarshal(data, &fc)
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	c.Override(fc)
+	if fc.Profiles != nil {
+		c.Profiles = fc.Profiles
+	}
+	return nil
+}
+
+// applyEnv overrides the config with the VOCA_* environment variables that are set.
+func (c *Config) applyEnv() {
+	c.Override(Config{
+		RemoteURL:           os.Getenv("VOCA_REMOTE_URL"),
+		Branch:              os.Getenv("VOCA_BRANCH"),
+		LocalPath:           os.Getenv("VOCA_LOCAL_PATH"),
+		TableName:           os.Getenv("VOCA_TABLE_NAME"),
+		StorageMode:         os.Getenv("VOCA_STORAGE_MODE"),
+		Backend:             os.Getenv("VOCA_BACKEND"),
+		TargetLanguage:      os.Getenv("VOCA_TARGET_LANGUAGE"),
+		ExplanationLanguage: os.Getenv("VOCA_EXPLANATION_LANGUAGE"),
+		Profile:             os.Getenv("VOCA_PROFILE"),
+	})
+}
+
+// Override copies every non-empty value of o except Profiles into the config,
+// e.g. the values parsed from command-line flags.
+// A new target language without a table name of its own drops the table name set so far,
+// so that the words of that language are kept in the table derived from it.
+func (c *Config) Override(o Config) {
+	if o.TargetLanguage != "" && o.TableName == "" && languageCode(o.TargetLanguage) != languageCode(c.TargetLanguage) {
+		c.TableName = ""
+	}
+	if o.RemoteURL != "" {
+		c.RemoteURL = o.RemoteURL
+	}
+	if o.Branch != "" {
+		c.Branch = o.Branch
+	}
+	if o.LocalPath != "" {
+		c.LocalPath = o.LocalPath
+	}
+	if o.TableName != "" {
+		c.TableName = o.TableName
+	}
+	if o.StorageMode != "" {
+		c.StorageMode = o.StorageMode
+	}
+	if o.Backend != "" {
+		c.Backend = o.Backend
+	}
+	if o.TargetLanguage != "" {
+		c.TargetLanguage = o.TargetLanguage
+	}
+	if o.ExplanationLanguage != "" {
+		c.ExplanationLanguage = o.ExplanationLanguage
+	}
+	if o.Profile != "" {
+		c.Profile = o.Profile
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVocabularyTableName(t *testing.T) {
+	const file = `{
+  "table_name": "my_words",
+  "target_language": "English",
+  "profiles": {
+    "german": { "target_language": "German", "explanation_language": "Spanish" },
+    "japanese": { "target_language": "Japanese", "table_name": "jp_words" }
+  }
+}`
+	tests := []struct {
+		name    string
+		profile string
+		env     map[string]string
+		flags   Config
+		want    string
+	}{
+		{name: "config file", want: "my_words"},
+		{name: "same target language keeps the table", flags: Config{TargetLanguage: "english"}, want: "my_words"},
+		{name: "target flag", flags: Config{TargetLanguage: "German"}, want: "deu__voca"},
+		{name: "target environment", env: map[string]string{"VOCA_TARGET_LANGUAGE": "French"}, want: "fra__voca"},
+		{
+			name:  "target and table flags",
+			flags: Config{TargetLanguage: "German", TableName: "de_words"},
+			want:  "de_words",
+		},
+		{
+			name: "target and table environment",
+			env:  map[string]string{"VOCA_TARGET_LANGUAGE": "German", "VOCA_TABLE_NAME": "de_words"},
+			want: "de_words",
+		},
+		{name: "profile", profile: "german", want: "deu__voca"},
+		{name: "profile with a table", profile: "japanese", want: "jp_words"},
+		{name: "table flag beats the profile", profile: "german", flags: Config{TableName: "other"}, want: "other"},
+		{name: "target flag beats the profile table", profile: "japanese", flags: Config{TargetLanguage: "Korean"}, want: "kor__voca"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			err := os.WriteFile(path, []byte(file), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("VOCA_CONFIG", path)
+			for _, name := range []string{"VOCA_TABLE_NAME", "VOCA_TARGET_LANGUAGE", "VOCA_PROFILE"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			c, err := Load(tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Override(tt.flags)
+			if got := c.VocabularyTableName(); got != tt.want {
+				t.Errorf("table = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LanguagePair is the language of the collected words and the language they are explained in.
+type LanguagePair struct {
+	TargetLanguage      string `json:"target_language"`
+	ExplanationLanguage string `json:"explanation_language"`
+}
+
+// languageCodes maps language names to ISO 639-2 codes used as table name prefixes.
+var languageCodes = map[string]string{
+	"chinese":    "zho",
+	"dutch":      "nld",
+	"english":    "eng",
+	"french":     "fra",
+	"german":     "deu",
+	"italian":    "ita",
+	"japanese":   "jpn",
+	"korean":     "kor",
+	"portuguese": "por",
+	"russian":    "rus",
+	"spanish":    "spa",
+}
+
+// languageCode returns the ISO 639-2 code of a language name,
+// or the lowercased name itself for languages without a known code.
+func languageCode(language string) string {
+	name := strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languageCodes[name]; ok {
+		return code
+	}
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// VocabularyTableName returns the configured table name,
+// or the one derived from the target language (e.g. "eng__voca" for English).
+func (c Config) VocabularyTableName() string {
+	if c.TableName != "" {
+		return c.TableName
+	}
+	return fmt.Sprintf("%s__voca", languageCode(c.TargetLanguage))
+}
+
+// LanguagePair returns the configured language pair.
+func (c Config) LanguagePair() LanguagePair {
+	return LanguagePair{
+		TargetLanguage:      c.TargetLanguage,
+		ExplanationLanguage: c.ExplanationLanguage,
+	}
+}
+
+// ApplyProfile overrides the config with the selected profile, if any.
+// A profile with a target language but no table of its own uses the table derived from its language,
+// not the table name set for the default language.
+func (c *Config) ApplyProfile() error {
+	if c.Profile == "" {
+		return nil
+	}
+	p, ok := c.Profiles[c.Profile]
+	if !ok {
+		return fmt.Errorf("profile not found: %s", c.Profile)
+	}
+	if p.TableName == "" && p.TargetLanguage != "" {
+		c.TableName = ""
+	}
+	c.Override(Config{
+		TargetLanguage:      p.TargetLanguage,
+		ExplanationLanguage: p.ExplanationLanguage,
+		TableName:           p.TableName,
+	})
+	return nil
+}
@@ -0,0 +1,122 @@
+package kindle
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	_ "modernc.org/sqlite"
+)
+
+// Lookup is a word looked up or highlighted on a Kindle.
+type Lookup struct {
+	Word string
+	// Usage is the sentence the word appeared in, if known.
+	Usage string
+	Book  string
+}
+
+// ReadVocabularyBuilder reads the lookups of the Vocabulary Builder database (vocab.db),
+// oldest first. When language is not empty, only words of that language (e.g. "en") are returned.
+func ReadVocabularyBuilder(path string, language string) ([]Lookup, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("error opening vocabulary builder database: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("error opening vocabulary builder database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT WORDS.word, IFNULL(LOOKUPS.usage, ''), IFNULL(BOOK_INFO.title, '')
+		FROM LOOKUPS
+		JOIN WORDS ON LOOKUPS.word_key = WORDS.id
+		LEFT JOIN BOOK_INFO ON LOOKUPS.book_key = BOOK_INFO.id
+		WHERE ? = '' OR WORDS.lang = ?
+		ORDER BY LOOKUPS.timestamp`,
+		language, language,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying vocabulary builder lookups: %w", err)
+	}
+	defer rows.Close()
+
+	lookups := make([]Lookup, 0)
+	for rows.Next() {
+		var l Lookup
+		err := rows.Scan(&l.Word, &l.Usage, &l.Book)
+		if err != nil {
+			return nil, fmt.Errorf("error reading vocabulary builder lookup: %w", err)
+		}
+		l.Word = strings.TrimSpace(l.Word)
+		l.Usage = strings.TrimSpace(l.Usage)
+		if l.Word != "" {
+			lookups = append(lookups, l)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading vocabulary builder lookups: %w", err)
+	}
+	return lookups, nil
+}
+
+// clippingSeparator ends every entry of My Clippings.txt.
+const clippingSeparator = "=========="
+
+// ReadClippings reads the highlights of My Clippings.txt that are at most maxWords long.
+// Longer highlights are passages rather than vocabulary, so they are skipped.
+func ReadClippings(path string, maxWords int) ([]Lookup, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening clippings: %w", err)
+	}
+	defer file.Close()
+
+	lookups := make([]Lookup, 0)
+	entry := make([]string, 0, 4)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line != clippingSeparator {
+			entry = append(entry, line)
+			continue
+		}
+		if l, ok := parseClipping(entry, maxWords); ok {
+			lookups = append(lookups, l)
+		}
+		entry = entry[:0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading clippings: %w", err)
+	}
+	return lookups, nil
+}
+
+// parseClipping parses one entry of My Clippings.txt:
+//
+//	Book Title (Author)
+//	- Your Highlight on page 12 | Location 180-181 | Added on Monday, 1 January 2024 10:00:00
+//
+//	highlighted text
+func parseClipping(entry []string, maxWords int) (Lookup, bool) {
+	if len(entry) < 3 || !strings.Contains(entry[1], "Highlight") {
+		return Lookup{}, false
+	}
+
+	text := strings.Join(entry[2:], " ")
+	text = strings.TrimFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '\'')
+	})
+	words := strings.Fields(text)
+	if len(words) == 0 || len(words) > maxWords {
+		return Lookup{}, false
+	}
+	return Lookup{
+		Word: strings.Join(words, " "),
+		Book: entry[0],
+	}, true
+}
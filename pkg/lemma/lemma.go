@@ -0,0 +1,182 @@
+package lemma
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed inflections.tsv
+var inflectionsData string
+
+// inflections maps irregular inflections to their lemma.
+// Words mapped to themselves are lemmas that only look inflected (e.g. "news"),
+// so the suffix rules are not applied to them.
+var inflections = parseInflections(inflectionsData)
+
+// minimumStemLength keeps suffix rules from producing very short stems, such as "be" from "bed".
+const minimumStemLength = 3
+
+// maxPhraseLemmas caps the lemma combinations generated for a phrase.
+const maxPhraseLemmas = 64
+
+func parseInflections(data string) map[string]string {
+	m := make(map[string]string)
+	for line := range strings.SplitSeq(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		m[fields[0]] = fields[1]
+	}
+	return m
+}
+
+// Lemmas returns the possible lemmas of a word or phrase, including the word or phrase itself.
+// The lemmas of a phrase are every combination of the lemmas of its words.
+func Lemmas(phrase string) []string {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) == 0 {
+		return []string{}
+	}
+
+	results := wordLemmas(words[0])
+	for _, word := range words[1:] {
+		next := make([]string, 0, len(results))
+	combine:
+		for _, prefix := range results {
+			for _, l := range wordLemmas(word) {
+				next = append(next, prefix+" "+l)
+				if len(next) >= maxPhraseLemmas {
+					break combine
+				}
+			}
+		}
+		results = next
+	}
+	return results
+}
+
+// wordLemmas returns the word itself followed by the lemmas it may be an inflection of.
+func wordLemmas(word string) []string {
+	lemmas := []string{word}
+	if l, ok := inflections[word]; ok {
+		if l == word {
+			return lemmas
+		}
+		// keep applying the suffix rules, since "leaves" is both "leaf" and "leave"
+		lemmas = append(lemmas, l)
+	}
+
+	add := func(stem string) {
+		if len(stem) < minimumStemLength {
+			return
+		}
+		for _, l := range lemmas {
+			if l == stem {
+				return
+			}
+		}
+		lemmas = append(lemmas, stem)
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		add(strings.TrimSuffix(word, "ies") + "y")
+	case strings.HasSuffix(word, "es"):
+		add(strings.TrimSuffix(word, "es"))
+		add(strings.TrimSuffix(word, "s"))
+	case strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") &&
+		!strings.HasSuffix(word, "is"):
+		add(strings.TrimSuffix(word, "s"))
+	case strings.HasSuffix(word, "ied"):
+		add(strings.TrimSuffix(word, "ied") + "y")
+	case strings.HasSuffix(word, "ed"):
+		stem := strings.TrimSuffix(word, "ed")
+		add(stem)
+		add(stem + "e")
+		add(undouble(stem))
+	case strings.HasSuffix(word, "ing"):
+		stem := strings.TrimSuffix(word, "ing")
+		add(stem)
+		add(stem + "e")
+		add(undouble(stem))
+		if strings.HasSuffix(stem, "y") {
+			// dying, lying, tying
+			add(strings.TrimSuffix(stem, "y") + "ie")
+		}
+	}
+	return lemmas
+}
+
+// undouble removes a doubled final consonant, as in "stopp" from "stopped".
+func undouble(stem string) string {
+	n := len(stem)
+	if n < 2 || stem[n-1] != stem[n-2] || strings.ContainsRune("aeiouyslz", rune(stem[n-1])) {
+		return stem
+	}
+	return stem[:n-1]
+}
+
+// Related reports whether two words or phrases may share a lemma.
+func Related(a, b string) bool {
+	lemmasA := Lemmas(a)
+	for _, l := range Lemmas(b) {
+		for _, la := range lemmasA {
+			if l == la {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Clusters groups the words that may share a lemma, in the order they were given.
+// Words unrelated to any other word are left out.
+func Clusters(words []string) [][]string {
+	parent := make([]int, len(words))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owner := make(map[string]int)
+	for i, word := range words {
+		for _, l := range Lemmas(word) {
+			if j, ok := owner[l]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				owner[l] = i
+			}
+		}
+	}
+
+	groups := make(map[int][]string)
+	order := make([]int, 0)
+	for i, word := range words {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], word)
+	}
+
+	clusters := make([][]string, 0)
+	for _, root := range order {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+	return clusters
+}
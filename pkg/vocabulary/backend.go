@@ -0,0 +1,193 @@
+package vocabulary
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// BackendKind selects how the tables of a store are kept on disk.
+type BackendKind string
+
+const (
+	// BackendCSV keeps every table in its own CSV file.
+	BackendCSV BackendKind = "csv"
+	// BackendSQLite keeps every table in a single SQLite database file.
+	BackendSQLite BackendKind = "sqlite"
+	// BackendJSON keeps every table in a single JSON file.
+	BackendJSON BackendKind = "json"
+)
+
+// Backend stores the tables of the vocabulary store.
+//
+// Every backend follows the semantics of csvstore: records are maps of column to value,
+// Insert fills in an empty id, created_at and updated_at, Update sets updated_at,
+// values of unknown columns are ignored, rows keep their insertion order,
+// and conditions compare numbers numerically and anything else as strings.
+type Backend interface {
+	CheckTableExists(tableName string) bool
+	CreateTable(tableName string, columns []string) error
+	Columns(tableName string) ([]string, error)
+	AddColumn(tableName, column, defaultValue string) error
+	RenameColumn(tableName, oldColumn, newColumn string) error
+	// FillColumn sets the column of every row to the value computed from the row.
+	// Unlike Update it leaves updated_at alone, so migrations never change user timestamps.
+	FillColumn(tableName, column string, value func(record csvstore.CSVRecord) string) error
+	Insert(tableName string, record csvstore.CSVRecord) (csvstore.CSVRecord, error)
+	Query(tableName string, conditions []csvstore.QueryCondition) (*csvstore.QueryResult, error)
+	QuerySortedRange(tableName, sortField, sortBy string, limit int) (*csvstore.QueryResult, error)
+	Update(
+		tableName string,
+		updates csvstore.CSVRecord,
+		conditions []csvstore.QueryCondition,
+	) (*csvstore.QueryResult, error)
+	Delete(tableName string, conditions []csvstore.QueryCondition) (*csvstore.QueryResult, error)
+}
+
+// openBackend opens the backend of the kind in the store directory.
+func openBackend(kind BackendKind, dir string) (Backend, error) {
+	switch kind {
+	case BackendCSV:
+		return newCSVBackend(dir)
+	case BackendSQLite:
+		return newSQLiteBackend(dir)
+	case BackendJSON:
+		return newJSONBackend(dir)
+	}
+	return nil, fmt.Errorf("unsupported backend: %s", kind)
+}
+
+// newRow returns the record to insert into a table with the columns,
+// filling in the id and timestamps the way csvstore does.
+func newRow(columns []string, record csvstore.CSVRecord) csvstore.CSVRecord {
+	row := make(csvstore.CSVRecord, len(columns))
+	for _, column := range columns {
+		row[column] = record[column]
+	}
+	if row["id"] == "" && slices.Contains(columns, "id") {
+		row["id"] = strconv.Itoa(int(time.Now().UnixNano()))
+	}
+	now := time.Now().Format(time.RFC3339Nano)
+	for _, column := range []string{"created_at", "updated_at"} {
+		if row[column] == "" && slices.Contains(columns, column) {
+			row[column] = now
+		}
+	}
+	return row
+}
+
+// updateRow applies the updates of known columns to a copy of the row and sets updated_at.
+func updateRow(columns []string, row csvstore.CSVRecord, updates csvstore.CSVRecord) csvstore.CSVRecord {
+	updated := maps.Clone(row)
+	for column, value := range updates {
+		if slices.Contains(columns, column) {
+			updated[column] = value
+		}
+	}
+	if slices.Contains(columns, "updated_at") {
+		updated["updated_at"] = time.Now().Format(time.RFC3339Nano)
+	}
+	return updated
+}
+
+// sortedRange sorts the rows by the field and returns at most limit of them,
+// with the same ordering and errors as csvstore.QuerySortedRange.
+func sortedRange(
+	tableName string,
+	rows []csvstore.CSVRecord,
+	sortField string,
+	sortBy string,
+	limit int,
+) (*csvstore.QueryResult, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("limit (%d) cannot be negative", limit)
+	}
+	if sortBy != "asc" && sortBy != "desc" {
+		return nil, fmt.Errorf("sortBy must be either 'asc' or 'desc', got '%s'", sortBy)
+	}
+	if len(rows) == 0 {
+		return &csvstore.QueryResult{Records: []csvstore.CSVRecord{}, Count: 0}, nil
+	}
+	if _, ok := rows[0][sortField]; !ok {
+		return nil, fmt.Errorf("sortField '%s' does not exist in table '%s'", sortField, tableName)
+	}
+
+	sorted := slices.Clone(rows)
+	slices.SortStableFunc(sorted, func(a, b csvstore.CSVRecord) int {
+		result := compareValues(a[sortField], b[sortField])
+		if sortBy == "desc" {
+			return -result
+		}
+		return result
+	})
+	sorted = sorted[:min(limit, len(sorted))]
+	return &csvstore.QueryResult{Records: sorted, Count: len(sorted)}, nil
+}
+
+// matchesConditions reports whether the record matches every condition, as csvstore does.
+func matchesConditions(record csvstore.CSVRecord, conditions []csvstore.QueryCondition) bool {
+	for _, condition := range conditions {
+		if !matchesCondition(record, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesCondition(record csvstore.CSVRecord, condition csvstore.QueryCondition) bool {
+	value, exists := record[condition.Column]
+	if !exists {
+		return false
+	}
+
+	switch condition.Operator {
+	case "=", "==":
+		return value == condition.Value
+	case "!=":
+		return value != condition.Value
+	case ">":
+		return compareValues(value, condition.Value) > 0
+	case "<":
+		return compareValues(value, condition.Value) < 0
+	case ">=":
+		return compareValues(value, condition.Value) >= 0
+	case "<=":
+		return compareValues(value, condition.Value) <= 0
+	case "contains":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(condition.Value))
+	case "starts_with":
+		return strings.HasPrefix(strings.ToLower(value), strings.ToLower(condition.Value))
+	case "ends_with":
+		return strings.HasSuffix(strings.ToLower(value), strings.ToLower(condition.Value))
+	}
+	return false
+}
+
+// compareValues orders two column values as numbers or timestamps when both parse as such,
+// and as strings otherwise. Filters and sorting both use it, so they agree on mixed values.
+func compareValues(a, b string) int {
+	numA, errA := strconv.ParseFloat(a, 64)
+	numB, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		}
+		return 0
+	}
+
+	timeA, errA := time.Parse(time.RFC3339Nano, a)
+	timeB, errB := time.Parse(time.RFC3339Nano, b)
+	if errA == nil && errB == nil {
+		return timeA.Compare(timeB)
+	}
+
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,427 @@
+package vocabulary
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+var backendKinds = []BackendKind{BackendCSV, BackendSQLite, BackendJSON}
+
+var conformanceColumns = []string{"id", "word", "read_count", "created_at", "updated_at"}
+
+// newConformanceBackend opens an empty backend with a words table holding the rows, in order.
+func newConformanceBackend(t *testing.T, kind BackendKind, rows ...csvstore.CSVRecord) Backend {
+	t.Helper()
+	backend, err := openBackend(kind, t.TempDir())
+	if err != nil {
+		t.Fatalf("opening %s backend: %v", kind, err)
+	}
+	err = backend.CreateTable("words", conformanceColumns)
+	if err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+	for _, row := range rows {
+		_, err := backend.Insert("words", row)
+		if err != nil {
+			t.Fatalf("inserting %v: %v", row, err)
+		}
+	}
+	return backend
+}
+
+// words returns the word column of the records.
+func words(records []csvstore.CSVRecord) []string {
+	result := make([]string, 0, len(records))
+	for _, record := range records {
+		result = append(result, record["word"])
+	}
+	return result
+}
+
+const fixtureUpdatedAt = "2024-01-01T00:00:00Z"
+
+// fixtureRows are inserted in this order; read counts mix numeric and string ordering.
+var fixtureRows = []csvstore.CSVRecord{
+	{"id": "1", "updated_at": fixtureUpdatedAt, "word": "Apple", "read_count": "10"},
+	{"id": "2", "updated_at": fixtureUpdatedAt, "word": "banana", "read_count": "9"},
+	{"id": "3", "updated_at": fixtureUpdatedAt, "word": "cherry pie", "read_count": "2"},
+	{"id": "4", "updated_at": fixtureUpdatedAt, "word": "date", "read_count": "n/a"},
+}
+
+func TestBackendTables(t *testing.T) {
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend, err := openBackend(kind, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backend.CheckTableExists("words") {
+				t.Error("table exists before it was created")
+			}
+			err = backend.CreateTable("words", conformanceColumns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !backend.CheckTableExists("words") {
+				t.Error("table does not exist after it was created")
+			}
+			err = backend.CreateTable("words", conformanceColumns)
+			if err == nil {
+				t.Error("creating an existing table succeeded")
+			}
+			columns, err := backend.Columns("words")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(columns, conformanceColumns) {
+				t.Errorf("columns = %v, want %v", columns, conformanceColumns)
+			}
+			_, err = backend.Columns("missing")
+			if err == nil {
+				t.Error("columns of a missing table succeeded")
+			}
+		})
+	}
+}
+
+func TestBackendInsert(t *testing.T) {
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend := newConformanceBackend(t, kind)
+
+			before := time.Now()
+			inserted, err := backend.Insert("words", csvstore.CSVRecord{"word": "apple", "unknown": "ignored"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inserted["id"] == "" {
+				t.Error("Insert did not fill in the id")
+			}
+			for _, column := range []string{"created_at", "updated_at"} {
+				ts, err := time.Parse(time.RFC3339Nano, inserted[column])
+				if err != nil {
+					t.Errorf("%s = %q is not a timestamp", column, inserted[column])
+				} else if ts.Before(before.Add(-time.Second)) {
+					t.Errorf("%s = %s is older than the insert", column, ts)
+				}
+			}
+			if _, ok := inserted["unknown"]; ok {
+				t.Error("Insert kept the value of an unknown column")
+			}
+			if inserted["read_count"] != "" {
+				t.Errorf("read_count = %q, want empty", inserted["read_count"])
+			}
+
+			given := csvstore.CSVRecord{
+				"id":         "42",
+				"word":       "banana",
+				"created_at": "2024-01-01T00:00:00Z",
+				"updated_at": "2024-01-02T00:00:00Z",
+			}
+			inserted, err = backend.Insert("words", given)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for column, value := range given {
+				if inserted[column] != value {
+					t.Errorf("Insert changed %s to %q, want %q", column, inserted[column], value)
+				}
+			}
+
+			qResult, err := backend.Query("words", []csvstore.QueryCondition{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := words(qResult.Records); !slices.Equal(got, []string{"apple", "banana"}) {
+				t.Errorf("rows = %v, want insertion order", got)
+			}
+			if qResult.Count != len(qResult.Records) {
+				t.Errorf("Count = %d, want %d", qResult.Count, len(qResult.Records))
+			}
+		})
+	}
+}
+
+func TestBackendQueryOperators(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition csvstore.QueryCondition
+		want      []string
+	}{
+		{"equal", csvstore.QueryCondition{Column: "word", Operator: "=", Value: "banana"}, []string{"banana"}},
+		{"double equal", csvstore.QueryCondition{Column: "id", Operator: "==", Value: "3"}, []string{"cherry pie"}},
+		{"equal is exact", csvstore.QueryCondition{Column: "word", Operator: "=", Value: "apple"}, []string{}},
+		{"equal is textual", csvstore.QueryCondition{Column: "read_count", Operator: "=", Value: "2.0"}, []string{}},
+		{"not equal", csvstore.QueryCondition{Column: "word", Operator: "!=", Value: "banana"}, []string{"Apple", "cherry pie", "date"}},
+		{"greater numeric", csvstore.QueryCondition{Column: "read_count", Operator: ">", Value: "9"}, []string{"Apple", "date"}},
+		{"less numeric", csvstore.QueryCondition{Column: "read_count", Operator: "<", Value: "9"}, []string{"cherry pie"}},
+		{"greater or equal", csvstore.QueryCondition{Column: "read_count", Operator: ">=", Value: "9"}, []string{"Apple", "banana", "date"}},
+		{"less or equal", csvstore.QueryCondition{Column: "read_count", Operator: "<=", Value: "9"}, []string{"banana", "cherry pie"}},
+		{"greater textual", csvstore.QueryCondition{Column: "word", Operator: ">", Value: "b"}, []string{"banana", "cherry pie", "date"}},
+		{"contains ignores case", csvstore.QueryCondition{Column: "word", Operator: "contains", Value: "PP"}, []string{"Apple"}},
+		{"starts with", csvstore.QueryCondition{Column: "word", Operator: "starts_with", Value: "ch"}, []string{"cherry pie"}},
+		{"ends with", csvstore.QueryCondition{Column: "word", Operator: "ends_with", Value: "NA"}, []string{"banana"}},
+		{"unknown operator", csvstore.QueryCondition{Column: "word", Operator: "like", Value: "banana"}, []string{}},
+		{"unknown column", csvstore.QueryCondition{Column: "missing", Operator: "=", Value: ""}, []string{}},
+	}
+
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend := newConformanceBackend(t, kind, fixtureRows...)
+			for _, tt := range tests {
+				qResult, err := backend.Query("words", []csvstore.QueryCondition{tt.condition})
+				if err != nil {
+					t.Errorf("%s: %v", tt.name, err)
+					continue
+				}
+				if got := words(qResult.Records); !slices.Equal(got, tt.want) {
+					t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				}
+			}
+
+			qResult, err := backend.Query("words", []csvstore.QueryCondition{
+				{Column: "read_count", Operator: ">", Value: "1"},
+				{Column: "word", Operator: "contains", Value: "an"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := words(qResult.Records); !slices.Equal(got, []string{"banana"}) {
+				t.Errorf("conditions are not all required: got %v", got)
+			}
+		})
+	}
+}
+
+func TestBackendUpdate(t *testing.T) {
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend := newConformanceBackend(t, kind, fixtureRows...)
+			original, err := backend.Query("words", []csvstore.QueryCondition{{Column: "id", Operator: "=", Value: "2"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uResult, err := backend.Update("words", csvstore.CSVRecord{"read_count": "11"}, []csvstore.QueryCondition{
+				{Column: "id", Operator: "=", Value: "2"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if uResult.Count != 1 {
+				t.Fatalf("updated %d rows, want 1", uResult.Count)
+			}
+			updated := uResult.Records[0]
+			if updated["read_count"] != "11" || updated["word"] != "banana" {
+				t.Errorf("updated row = %v", updated)
+			}
+			if updated["updated_at"] == fixtureUpdatedAt {
+				t.Error("Update did not set updated_at")
+			}
+			if updated["created_at"] != original.Records[0]["created_at"] {
+				t.Error("Update changed created_at")
+			}
+
+			qResult, err := backend.Query("words", []csvstore.QueryCondition{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := words(qResult.Records); !slices.Equal(got, words(fixtureRows)) {
+				t.Errorf("Update changed the row order: %v", got)
+			}
+			if qResult.Records[1]["read_count"] != "11" {
+				t.Errorf("Update was not persisted: %v", qResult.Records[1])
+			}
+
+			uResult, err = backend.Update("words", csvstore.CSVRecord{"read_count": "0"}, []csvstore.QueryCondition{
+				{Column: "word", Operator: "=", Value: "missing"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if uResult.Count != 0 {
+				t.Errorf("updated %d rows matching nothing", uResult.Count)
+			}
+		})
+	}
+}
+
+func TestBackendDelete(t *testing.T) {
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend := newConformanceBackend(t, kind, fixtureRows...)
+
+			dResult, err := backend.Delete("words", []csvstore.QueryCondition{
+				{Column: "read_count", Operator: "<=", Value: "9"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := words(dResult.Records); !slices.Equal(got, []string{"banana", "cherry pie"}) {
+				t.Errorf("deleted %v", got)
+			}
+			qResult, err := backend.Query("words", []csvstore.QueryCondition{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := words(qResult.Records); !slices.Equal(got, []string{"Apple", "date"}) {
+				t.Errorf("rows left = %v", got)
+			}
+		})
+	}
+}
+
+func TestBackendQuerySortedRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		sortBy  string
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{"ascending numbers before text", "read_count", "asc", 10, []string{"cherry pie", "banana", "Apple", "date"}, false},
+		{"descending", "read_count", "desc", 10, []string{"date", "Apple", "banana", "cherry pie"}, false},
+		{"limit", "read_count", "asc", 2, []string{"cherry pie", "banana"}, false},
+		{"zero limit", "read_count", "asc", 0, []string{}, false},
+		{"textual", "word", "asc", 10, []string{"Apple", "banana", "cherry pie", "date"}, false},
+		{"negative limit", "read_count", "asc", -1, nil, true},
+		{"bad direction", "read_count", "up", 10, nil, true},
+		{"unknown field", "missing", "asc", 10, nil, true},
+	}
+
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend := newConformanceBackend(t, kind, fixtureRows...)
+			for _, tt := range tests {
+				qResult, err := backend.QuerySortedRange("words", tt.field, tt.sortBy, tt.limit)
+				if tt.wantErr {
+					if err == nil {
+						t.Errorf("%s: got %v, want an error", tt.name, words(qResult.Records))
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s: %v", tt.name, err)
+					continue
+				}
+				if got := words(qResult.Records); !slices.Equal(got, tt.want) {
+					t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				}
+			}
+
+			empty := newConformanceBackend(t, kind)
+			qResult, err := empty.QuerySortedRange("words", "missing", "asc", 1)
+			if err != nil || qResult.Count != 0 {
+				t.Errorf("empty table: got %v, %v, want no rows and no error", qResult, err)
+			}
+		})
+	}
+}
+
+func TestBackendColumns(t *testing.T) {
+	for _, kind := range backendKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			backend := newConformanceBackend(t, kind, fixtureRows...)
+
+			err := backend.AddColumn("words", "status", "active")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = backend.AddColumn("words", "status", "active")
+			if err == nil {
+				t.Error("adding an existing column succeeded")
+			}
+			err = backend.RenameColumn("words", "read_count", "reads")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = backend.RenameColumn("words", "missing", "other")
+			if err == nil {
+				t.Error("renaming a missing column succeeded")
+			}
+			err = backend.RenameColumn("words", "word", "status")
+			if err == nil {
+				t.Error("renaming onto an existing column succeeded")
+			}
+
+			columns, err := backend.Columns("words")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"id", "word", "reads", "created_at", "updated_at", "status"}
+			if !slices.Equal(columns, want) {
+				t.Errorf("columns = %v, want %v", columns, want)
+			}
+
+			qResult, err := backend.Query("words", []csvstore.QueryCondition{{Column: "id", Operator: "=", Value: "1"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := qResult.Records[0]
+			if record["status"] != "active" || record["reads"] != "10" {
+				t.Errorf("row after column changes = %v", record)
+			}
+			if _, ok := record["read_count"]; ok {
+				t.Error("renamed column is still read")
+			}
+
+			inserted, err := backend.Insert("words", csvstore.CSVRecord{"word": "elderberry", "status": "mastered"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inserted["status"] != "mastered" {
+				t.Errorf("new column not written on insert: %v", inserted)
+			}
+
+			err = backend.FillColumn("words", "status", func(record csvstore.CSVRecord) string {
+				return record["word"] + "!"
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			qResult, err = backend.Query("words", []csvstore.QueryCondition{{Column: "id", Operator: "=", Value: "1"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if qResult.Records[0]["status"] != "Apple!" {
+				t.Errorf("FillColumn wrote %q", qResult.Records[0]["status"])
+			}
+			if qResult.Records[0]["updated_at"] != record["updated_at"] {
+				t.Error("FillColumn changed updated_at")
+			}
+			err = backend.FillColumn("words", "missing", func(csvstore.CSVRecord) string { return "" })
+			if err == nil {
+				t.Error("filling a missing column succeeded")
+			}
+		})
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"9", "10", -1},
+		{"2.5", "2.50", 0},
+		{"10", "n/a", -1},
+		{"apple", "banana", -1},
+		{"2024-06-01T00:00:00Z", "2024-06-01T00:00:00.1Z", -1},
+		{"2024-06-01T09:00:00+09:00", "2024-06-01T00:00:00Z", 0},
+		{"2024-06-02T00:00:00Z", "2024-06-01T23:00:00-02:00", -1},
+		{"2024-06-01T00:00:00Z", "n/a", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareValues(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareValues(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareValues(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareValues(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
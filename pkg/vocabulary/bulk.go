@@ -0,0 +1,44 @@
+package vocabulary
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadVocabularyEntries reads one entry per line, with an optional context after a tab:
+//
+//	sea change	The election marked a sea change in policy.
+//
+// Blank lines and lines starting with # are ignored. Every entry gets opts,
+// with the context of the line, if any, replacing opts.Context.
+func ReadVocabularyEntries(r io.Reader, opts AddOptions) ([]VocabularyEntry, error) {
+	entries := make([]VocabularyEntry, 0)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		word, context, hasContext := strings.Cut(line, "\t")
+		word = strings.TrimSpace(word)
+		if word == "" {
+			return nil, fmt.Errorf("line %d: missing word before the context", lineNumber)
+		}
+
+		entryOpts := opts
+		if context = strings.TrimSpace(context); hasContext && context != "" {
+			entryOpts.Context = context
+		}
+		entries = append(entries, VocabularyEntry{Word: word, AddOptions: entryOpts})
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("error reading line %d: %w", lineNumber+1, err)
+	}
+	return entries, nil
+}
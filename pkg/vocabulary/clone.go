@@ -0,0 +1,221 @@
+package vocabulary
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// dailyClonePrefix starts the name of the temporary clones that earlier versions of voca made every day.
+const dailyClonePrefix = "csv__voca--"
+
+// cachedClonePath returns the persistent clone of the remote in the voca cache directory.
+// Every remote and branch gets its own clone, named after the repository.
+func (s *store) cachedClonePath() (string, error) {
+	if s.opts.RemoteURL == "" {
+		return "", fmt.Errorf("remote URL of the CSV store repository is not configured")
+	}
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting cache directory: %w", err)
+	}
+	repository := strings.TrimSuffix(path.Base(strings.ReplaceAll(s.opts.RemoteURL, ":", "/")), ".git")
+	sum := sha256.Sum256([]byte(s.opts.RemoteURL + "#" + s.opts.Branch))
+	return filepath.Join(cacheDir, "stores", fmt.Sprintf("%s-%x", repository, sum[:4])), nil
+}
+
+// prepareGitStore clones the store repository, or brings an existing clone up to date with the remote.
+// Old daily clones are collected into the cached clone.
+func (s *store) prepareGitStore(csvStoreFilepath string) error {
+	if !checkIsFolderExists(csvStoreFilepath) {
+		err := s.cloneGitStore(csvStoreFilepath)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := s.refreshClone()
+		if err != nil {
+			// the store stays usable offline, and the next sync catches up
+			log.Printf("error updating store from the remote: %v\n", err)
+		}
+	}
+
+	if s.opts.LocalPath == "" {
+		s.collectDailyClones()
+	}
+	return nil
+}
+
+// cloneGitStore clones the store repository.
+func (s *store) cloneGitStore(csvStoreFilepath string) error {
+	if s.opts.RemoteURL == "" {
+		return fmt.Errorf("remote URL of the CSV store repository is not configured")
+	}
+	err := os.MkdirAll(filepath.Dir(csvStoreFilepath), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating store directory: %w", err)
+	}
+	cloneArgs := []string{"clone"}
+	if s.opts.Branch != "" {
+		cloneArgs = append(cloneArgs, "--branch", s.opts.Branch)
+	}
+	cloneArgs = append(cloneArgs, s.opts.RemoteURL, csvStoreFilepath)
+	cmd := exec.Command("git", cloneArgs...)
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error cloning CSV store repository: %w", err)
+	}
+	return nil
+}
+
+// refreshClone fetches the remote and brings its changes into a clean clone,
+// fast-forwarding when possible and merging otherwise.
+// A clone with uncommitted changes is left as it is until the next sync.
+func (s *store) refreshClone() error {
+	status, err := s.git("status", "--porcelain")
+	if err != nil || status != "" {
+		return err
+	}
+	branch, err := s.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+	_, err = s.git("fetch", remoteName)
+	if err != nil {
+		return err
+	}
+	remoteBranch := remoteName + "/" + branch
+	if _, err := s.git("rev-parse", "--verify", "--quiet", remoteBranch); err != nil {
+		return nil
+	}
+	return s.catchUp(remoteBranch)
+}
+
+// catchUp brings the commits of rev into HEAD, fast-forwarding when HEAD has no commits of its own.
+func (s *store) catchUp(rev string) error {
+	behind, ahead, err := s.countDivergence(rev)
+	if err != nil {
+		return err
+	}
+	switch {
+	case behind == 0:
+		return nil
+	case ahead == 0:
+		_, err = s.git("merge", "--ff-only", rev)
+		return err
+	}
+	return s.mergeRemote(rev)
+}
+
+// collectDailyClones removes the temporary clones that earlier versions of voca made every day,
+// first moving their uncommitted changes and unpushed commits into the store.
+// Clones of other remotes or branches are left untouched when they have anything to move,
+// and removed otherwise.
+func (s *store) collectDailyClones() {
+	paths, err := filepath.Glob(filepath.Join(os.TempDir(), dailyClonePrefix+"*"))
+	if err != nil || len(paths) == 0 {
+		return
+	}
+	status, err := s.git("status", "--porcelain")
+	if err != nil || status != "" {
+		// commits are only moved into a clean store
+		return
+	}
+	for _, p := range paths {
+		if !checkIsFolderExists(p) {
+			continue
+		}
+		err := s.collectDailyClone(p)
+		if err != nil {
+			log.Printf("error collecting old clone %s: %v\n", p, err)
+		}
+	}
+}
+
+func (s *store) collectDailyClone(clonePath string) error {
+	unlock, err := lockFile(lockPath(clonePath))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(lockPath(clonePath))
+	defer unlock()
+
+	old := &store{storePath: clonePath, opts: s.opts}
+	if !old.checkIsGitRepo() {
+		return fmt.Errorf("not a git repository")
+	}
+	isOurs, err := s.isSameBranch(old)
+	if err != nil {
+		return err
+	}
+	if !isOurs {
+		// not ours to move, so keep it unless there is nothing to lose
+		status, err := old.git("status", "--porcelain")
+		if err != nil || status != "" {
+			return err
+		}
+		unpushed, err := old.countUnpushed()
+		if err != nil || unpushed > 0 {
+			return err
+		}
+		return os.RemoveAll(clonePath)
+	}
+
+	status, err := old.git("status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if status != "" {
+		err = old.commitChanges("save", "save uncommitted changes")
+		if err != nil {
+			return fmt.Errorf("error committing changes: %w", err)
+		}
+	}
+
+	ref := "refs/voca/" + filepath.Base(clonePath)
+	_, err = s.git("fetch", "--quiet", clonePath, "+HEAD:"+ref)
+	if err != nil {
+		return err
+	}
+	defer s.git("update-ref", "-d", ref)
+
+	behind, _, err := s.countDivergence(ref)
+	if err != nil {
+		return err
+	}
+	if behind > 0 {
+		err = s.catchUp(ref)
+		if err != nil {
+			return err
+		}
+		log.Printf("moved %d unpushed commit(s) from %s\n", behind, clonePath)
+		err = s.markPendingPush(fmt.Errorf("commits moved from %s", clonePath))
+		if err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(clonePath)
+}
+
+// isSameBranch reports whether the other clone has the branch of the store checked out,
+// from the same remote.
+func (s *store) isSameBranch(other *store) (bool, error) {
+	remoteURL, _ := other.git("remote", "get-url", remoteName)
+	if remoteURL != s.opts.RemoteURL {
+		return false, nil
+	}
+	branch, err := s.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return false, err
+	}
+	otherBranch, err := other.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return false, err
+	}
+	return otherBranch == branch, nil
+}
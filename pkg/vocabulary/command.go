@@ -1,7 +1,9 @@
 package vocabulary
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os/exec"
 	"strings"
 	"time"
@@ -14,37 +16,13 @@ func (s *store) checkIsGitRepo() bool {
 	return err == nil
 }
 
-func (s *store) checkIsSynced() error {
-	cmdRevParse := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	cmdRevParse.Dir = s.storePath
-	revParseOutput, err := cmdRevParse.Output()
-	if err != nil {
-		return fmt.Errorf("error running git rev-parse: %w", err)
-	}
-	cmdRevList := exec.Command(
-		"git",
-		"rev-list",
-		"--left-right",
-		"--count",
-		fmt.Sprintf("origin/%s...HEAD", strings.TrimSpace(string(revParseOutput))),
-	)
-	cmdRevList.Dir = s.storePath
-	revListOutput, err := cmdRevList.Output()
-	if err != nil {
-		return fmt.Errorf("error running git rev-list: %w", err)
-	}
+// vocaOperationTrailer is the git trailer naming the operation of a commit made by voca.
+const vocaOperationTrailer = "Voca-Operation"
 
-	counts := strings.Fields(string(revListOutput))
-	if len(counts) != 2 {
-		return fmt.Errorf("unexpected output from git rev-list: %s", string(revListOutput))
-	}
-	if counts[0] != "0" || counts[1] != "0" {
-		return fmt.Errorf("local repository is not synced with remote: %s", string(revListOutput))
-	}
-	return nil
-}
+// legacyCommitPrefix starts the subject of the commits voca made before operations were recorded.
+const legacyCommitPrefix = "chore: sync vocabulary at "
 
-func (s *store) commitChanges() error {
+func (s *store) commitChanges(operation string, summary string) error {
 	formattedNow := time.Now().Format("2006-01-02 15:04:05 (-0700)")
 	cmdAdd := exec.Command("git", "add", "-A")
 	cmdAdd.Dir = s.storePath
@@ -57,7 +35,11 @@ func (s *store) commitChanges() error {
 		"git",
 		"commit",
 		"-m",
-		fmt.Sprintf("chore: sync vocabulary at %s", formattedNow),
+		fmt.Sprintf("chore: %s", summary),
+		"-m",
+		fmt.Sprintf("Synced at %s.", formattedNow),
+		"-m",
+		fmt.Sprintf("%s: %s", vocaOperationTrailer, operation),
 	)
 	cmdCommit.Dir = s.storePath
 	err = cmdCommit.Run()
@@ -68,36 +50,66 @@ func (s *store) commitChanges() error {
 	return nil
 }
 
-func (s *store) pushChanges() error {
-	cmdPush := exec.Command("git", "push", "--force-with-lease")
-	cmdPush.Dir = s.storePath
-	err := cmdPush.Run()
-	if err != nil {
-		return fmt.Errorf("error running git push: %w", err)
+// syncStore commits every change as one operation, described by summary,
+// merges the changes of the remote and pushes the result.
+// The commit is kept when the remote cannot be reached; the store is then marked
+// as pending a push, which the next sync or `voca sync` completes.
+// Within a transaction the operation is only recorded, and synced when the transaction is committed.
+func (s *store) syncStore(operation string, summary string) error {
+	if s.tx != nil {
+		s.tx.record(operation, summary)
+		return nil
+	}
+	if s.opts.StorageMode == StorageModeLocal {
+		return nil
 	}
 
-	return nil
-}
-
-func (s *store) syncStore() error {
 	isGitRepo := s.checkIsGitRepo()
 	if !isGitRepo {
 		return fmt.Errorf("store is not a git repository")
 	}
-	err := s.checkIsSynced()
+
+	status, err := s.git("status", "--porcelain")
 	if err != nil {
-		return fmt.Errorf("store is not synced: %w", err)
+		return err
+	}
+	if status != "" {
+		err = s.commitChanges(operation, summary)
+		if err != nil {
+			return fmt.Errorf("error committing changes: %w", err)
+		}
 	}
 
-	err = s.commitChanges()
+	err = s.pullAndPush()
 	if err != nil {
-		return fmt.Errorf("error committing changes: %w", err)
+		log.Printf("changes were saved locally but not pushed: %v\n", err)
+		markErr := s.markPendingPush(err)
+		if markErr != nil {
+			return fmt.Errorf("error marking pending push: %w", markErr)
+		}
+		s.logPendingStatus()
+		return nil
 	}
 
-	err = s.pushChanges()
+	return s.clearPendingPush()
+}
+
+// git runs a git command in the store and returns its trimmed output.
+func (s *store) git(args ...string) (string, error) {
+	return gitIn(s.storePath, args...)
+}
+
+// gitIn runs a git command in the directory and returns its trimmed output.
+func gitIn(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("error pushing changes: %w", err)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("error running git %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("error running git %s: %w", args[0], err)
 	}
-
-	return nil
+	return strings.TrimSpace(string(output)), nil
 }
@@ -0,0 +1,173 @@
+package vocabulary
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// csvBackend keeps every table in its own CSV file, using csvstore.
+type csvBackend struct {
+	*csvstore.CSVStore
+}
+
+func newCSVBackend(dir string) (*csvBackend, error) {
+	cs, err := csvstore.NewCSVStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &csvBackend{CSVStore: cs}, nil
+}
+
+func (b *csvBackend) Columns(tableName string) ([]string, error) {
+	t, err := readCSVTable(b.GetTablePath(tableName))
+	if err != nil {
+		return nil, err
+	}
+	return t.headers, nil
+}
+
+func (b *csvBackend) AddColumn(tableName, column, defaultValue string) error {
+	t, err := readCSVTable(b.GetTablePath(tableName))
+	if err != nil {
+		return err
+	}
+	if slices.Contains(t.headers, column) {
+		return fmt.Errorf("column %s already exists in %s", column, tableName)
+	}
+	t.headers = append(t.headers, column)
+	for i := range t.rows {
+		t.rows[i] = append(t.rows[i], defaultValue)
+	}
+	return t.write()
+}
+
+func (b *csvBackend) RenameColumn(tableName, oldColumn, newColumn string) error {
+	t, err := readCSVTable(b.GetTablePath(tableName))
+	if err != nil {
+		return err
+	}
+	if slices.Contains(t.headers, newColumn) {
+		return fmt.Errorf("column %s already exists in %s", newColumn, tableName)
+	}
+	i := slices.Index(t.headers, oldColumn)
+	if i < 0 {
+		return fmt.Errorf("column %s not found in %s", oldColumn, tableName)
+	}
+	t.headers[i] = newColumn
+	return t.write()
+}
+
+func (b *csvBackend) FillColumn(tableName, column string, value func(record csvstore.CSVRecord) string) error {
+	t, err := readCSVTable(b.GetTablePath(tableName))
+	if err != nil {
+		return err
+	}
+	i := slices.Index(t.headers, column)
+	if i < 0 {
+		return fmt.Errorf("column %s not found in %s", column, tableName)
+	}
+	for _, row := range t.rows {
+		row[i] = value(t.record(row))
+	}
+	return t.write()
+}
+
+// csvTable is the raw content of a CSV table file.
+// csvstore has no way to change the columns of a table, so the CSV backend rewrites the file directly.
+type csvTable struct {
+	path    string
+	headers []string
+	rows    [][]string
+}
+
+func readCSVTable(path string) (*csvTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open table file: %w", err)
+	}
+	defer file.Close()
+
+	return parseCSVTable(path, file)
+}
+
+// parseCSVTable reads the table of the file at path from r, such as a version of it kept by git.
+func parseCSVTable(path string, r io.Reader) (*csvTable, error) {
+	t, err := parseRawCSVTable(path, r)
+	if err != nil {
+		return nil, err
+	}
+	// pad short rows so every row has a value for every column
+	for i, row := range t.rows {
+		if len(row) < len(t.headers) {
+			t.rows[i] = append(row, make([]string, len(t.headers)-len(row))...)
+		}
+	}
+	return t, nil
+}
+
+// readRawCSVTable reads a table file without padding its rows,
+// so that rows with fewer or more fields than the header can be found.
+func readRawCSVTable(path string) (*csvTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open table file: %w", err)
+	}
+	defer file.Close()
+
+	return parseRawCSVTable(path, file)
+}
+
+func parseRawCSVTable(path string, r io.Reader) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("table has no header: %s", path)
+	}
+	return &csvTable{path: path, headers: rows[0], rows: rows[1:]}, nil
+}
+
+func (t *csvTable) record(row []string) csvstore.CSVRecord {
+	record := make(csvstore.CSVRecord)
+	for i, header := range t.headers {
+		record[header] = row[i]
+	}
+	return record
+}
+
+func (t *csvTable) write() error {
+	file, err := os.Create(t.path)
+	if err != nil {
+		return fmt.Errorf("failed to create table file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	err = writer.Write(t.headers)
+	if err != nil {
+		return fmt.Errorf("failed to write headers: %w", err)
+	}
+	err = writer.WriteAll(t.rows)
+	if err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return nil
+}
+
+// recordsBy returns the rows of the table by the value of the key column.
+func (t *csvTable) recordsBy(key string) map[string]csvstore.CSVRecord {
+	records := make(map[string]csvstore.CSVRecord, len(t.rows))
+	for _, row := range t.rows {
+		record := t.record(row)
+		records[record[key]] = record
+	}
+	return records
+}
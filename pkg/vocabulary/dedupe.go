@@ -0,0 +1,67 @@
+package vocabulary
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jiyeol-lee/voca/pkg/lemma"
+)
+
+// findRelatedVocabulary returns a word in the store that may share a lemma with the given word,
+// or an empty string when there is none.
+func (s *store) findRelatedVocabulary(backend Backend, lowercaseWord string) (string, error) {
+	records, err := s.queryVocabulary(backend, Filter{})
+	if err != nil {
+		return "", err
+	}
+	for _, record := range records {
+		if record["word"] != lowercaseWord && lemma.Related(record["word"], lowercaseWord) {
+			return record["word"], nil
+		}
+	}
+	return "", nil
+}
+
+// ListLemmaClusters lists the groups of words in the store that may be forms of the same lemma.
+func (s *store) ListLemmaClusters() error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	records, err := s.queryVocabulary(backend, Filter{})
+	if err != nil {
+		return err
+	}
+	words := make([]string, 0, len(records))
+	for _, record := range records {
+		words = append(words, record["word"])
+	}
+	clusters := lemma.Clusters(words)
+	if len(clusters) == 0 {
+		fmt.Println("No duplicate lemmas found")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	_, err = writer.Write([]byte("Number\tWords\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for i, cluster := range clusters {
+		_, err := fmt.Fprintf(writer, "%d\t%s\n", i+1, strings.Join(cluster, ", "))
+		if err != nil {
+			return fmt.Errorf("failed to write cluster data: %w", err)
+		}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	fmt.Println("\nUse 'voca merge <into> <from>' to combine the entries of a cluster.")
+	return nil
+}
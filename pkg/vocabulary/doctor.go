@@ -0,0 +1,626 @@
+package vocabulary
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// problem is an integrity problem of a table found by doctor.
+type problem struct {
+	table string
+	// id is the id of the row, empty for problems of the table itself.
+	id          string
+	description string
+	fixable     bool
+}
+
+// doctor checks the tables of a store and, when fix is set, repairs them.
+type doctor struct {
+	backend  Backend
+	fix      bool
+	problems []problem
+}
+
+// rowRepair is a row of a table being checked: repaired starts as a copy of original
+// and is changed by the checks, or set to nil when the row is to be deleted.
+type rowRepair struct {
+	original csvstore.CSVRecord
+	repaired csvstore.CSVRecord
+}
+
+// timestampLayouts are the layouts hand-edited timestamps are read in, besides RFC 3339.
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// columnDefaults are the values of the rows when doctor adds a missing column.
+var columnDefaults = map[string]string{
+	"read_count": "0",
+	"status":     string(StatusActive),
+}
+
+// Doctor checks every vocabulary table of the store, their review logs, explanation caches and the schema table:
+// unique ids, integers, timestamps, blank or duplicate words, columns matching the schema
+// and, for the CSV backend, rows with fewer or more fields than the header.
+// The problems found are listed, and with fix the fixable ones are repaired and synced as one commit.
+// It returns the number of problems found and how many of them were fixed.
+func (s *store) Doctor(fix bool) (int, int, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	if fix {
+		unlock, err := s.lock()
+		if err != nil {
+			return 0, 0, err
+		}
+		defer unlock()
+	}
+
+	d := &doctor{backend: backend, fix: fix}
+	tables, err := d.checkSchemaTable()
+	if err != nil {
+		return 0, 0, err
+	}
+	if !slices.Contains(tables, s.opts.TableName) {
+		tables = append(tables, s.opts.TableName)
+	}
+	for _, table := range tables {
+		ids, merged, err := d.checkVocabularyTable(table)
+		if err != nil {
+			return 0, 0, err
+		}
+		err = d.checkReviewTable(reviewsTableName(table), ids, merged)
+		if err != nil {
+			return 0, 0, err
+		}
+		err = d.checkExplanationTable(explanationsTableName(table), ids)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	fixed := 0
+	if fix {
+		for _, p := range d.problems {
+			if p.fixable {
+				fixed++
+			}
+		}
+	}
+	if fixed > 0 {
+		defer func() {
+			err := s.syncStore("doctor", fmt.Sprintf("repair %d problem(s)", fixed))
+			if err != nil {
+				log.Printf("error syncing store: %v\n", err)
+			}
+		}()
+	}
+
+	if len(d.problems) == 0 {
+		return 0, 0, nil
+	}
+	err = d.print()
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(d.problems), fixed, nil
+}
+
+func (d *doctor) report(table string, id string, fixable bool, format string, args ...any) {
+	d.problems = append(d.problems, problem{
+		table:       table,
+		id:          id,
+		description: fmt.Sprintf(format, args...),
+		fixable:     fixable,
+	})
+}
+
+func (d *doctor) print() error {
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	_, err := writer.Write([]byte("Table\tRow\tProblem\tFix\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, p := range d.problems {
+		fix := "manual"
+		if p.fixable && d.fix {
+			fix = "fixed"
+		} else if p.fixable {
+			fix = "fixable"
+		}
+		_, err := fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", p.table, p.id, p.description, fix)
+		if err != nil {
+			return fmt.Errorf("failed to write problem data: %w", err)
+		}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
+
+// checkSchemaTable checks the schema table and returns the vocabulary tables it records.
+// The versions of tables that do not exist are removed.
+func (d *doctor) checkSchemaTable() ([]string, error) {
+	repairs, err := d.readTable(schemaTableName, schemaColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(repairs))
+	for i := range repairs {
+		record := repairs[i].repaired
+		id := record["id"]
+		tableName := record["table_name"]
+		switch {
+		case strings.TrimSpace(tableName) == "":
+			d.report(schemaTableName, id, true, "blank table_name")
+			repairs[i].repaired = nil
+		case slices.Contains(tables, tableName):
+			d.report(schemaTableName, id, true, "duplicate version of %s", tableName)
+			repairs[i].repaired = nil
+		case !d.backend.CheckTableExists(tableName):
+			d.report(schemaTableName, id, true, "version of missing table %s", tableName)
+			repairs[i].repaired = nil
+		default:
+			tables = append(tables, tableName)
+		}
+	}
+	d.checkIDs(schemaTableName, repairs)
+	for _, r := range repairs {
+		if r.repaired == nil {
+			continue
+		}
+		d.checkInt(schemaTableName, r.repaired, "version", "0")
+		d.checkRowTimestamps(schemaTableName, r.repaired)
+	}
+
+	err = d.apply(schemaTableName, repairs)
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// checkVocabularyTable checks a vocabulary table.
+// Rows with a blank word are deleted and duplicate words are merged into their first row.
+// It returns the ids of the rows kept and the ids of the merged rows with the ids they were merged into.
+func (d *doctor) checkVocabularyTable(table string) ([]string, map[string]string, error) {
+	repairs, err := d.readTable(table, vocabularyColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range repairs {
+		record := repairs[i].repaired
+		word, ok := record["word"]
+		if !ok {
+			continue
+		}
+		switch {
+		case normalizeWord(word) == "":
+			d.report(table, record["id"], true, "blank word")
+			repairs[i].repaired = nil
+		case normalizeWord(word) != word:
+			d.report(table, record["id"], true, "word %q is not trimmed and lowercase", word)
+			record["word"] = normalizeWord(word)
+		}
+	}
+	d.checkIDs(table, repairs)
+
+	for _, r := range repairs {
+		if r.repaired == nil {
+			continue
+		}
+		d.checkInt(table, r.repaired, "read_count", "0")
+		for _, column := range []string{"interval", "repetitions", "lapses"} {
+			d.checkInt(table, r.repaired, column, "")
+		}
+		d.checkEase(table, r.repaired)
+		d.checkRowTimestamps(table, r.repaired)
+		d.checkTimestamp(table, r.repaired, "due_at", "")
+		d.checkStatus(table, r.repaired)
+	}
+
+	merged := map[string]string{}
+	first := map[string]csvstore.CSVRecord{}
+	ids := make([]string, 0, len(repairs))
+	for i, r := range repairs {
+		if r.repaired == nil {
+			continue
+		}
+		word, ok := r.repaired["word"]
+		if !ok {
+			ids = append(ids, r.repaired["id"])
+			continue
+		}
+		target, ok := first[word]
+		if !ok {
+			first[word] = r.repaired
+			ids = append(ids, r.repaired["id"])
+			continue
+		}
+		d.report(table, r.repaired["id"], true, "duplicate of %q (%s)", word, target["id"])
+		maps.Copy(target, mergeRecords(target, r.repaired))
+		merged[r.repaired["id"]] = target["id"]
+		repairs[i].repaired = nil
+	}
+
+	err = d.apply(table, repairs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ids, merged, nil
+}
+
+// checkReviewTable checks the review log of a vocabulary table, given the ids of the words kept
+// and of the words merged into others. Reviews of merged words are moved and those of missing words deleted.
+// Review logs of tables that were not migrated to have one are skipped.
+func (d *doctor) checkReviewTable(table string, wordIDs []string, merged map[string]string) error {
+	if !d.backend.CheckTableExists(table) {
+		return nil
+	}
+	repairs, err := d.readTable(table, reviewColumns)
+	if err != nil {
+		return err
+	}
+
+	for i := range repairs {
+		record := repairs[i].repaired
+		wordID, ok := record["word_id"]
+		if !ok {
+			continue
+		}
+		if target, ok := merged[wordID]; ok {
+			d.report(table, record["id"], true, "review of merged duplicate %s", wordID)
+			record["word_id"] = target
+			continue
+		}
+		if !slices.Contains(wordIDs, wordID) {
+			d.report(table, record["id"], true, "review of missing word %q", wordID)
+			repairs[i].repaired = nil
+		}
+	}
+	d.checkIDs(table, repairs)
+
+	for _, r := range repairs {
+		if r.repaired == nil {
+			continue
+		}
+		d.checkRowTimestamps(table, r.repaired)
+		d.checkTimestamp(table, r.repaired, "reviewed_at", r.repaired["created_at"])
+		if mode, ok := r.repaired["mode"]; ok {
+			if !slices.Contains([]ReviewMode{ReviewModeStudy, ReviewModeStory, ReviewModeQuiz}, ReviewMode(mode)) {
+				d.report(table, r.repaired["id"], false, "unknown review mode %q", mode)
+			}
+		}
+	}
+
+	return d.apply(table, repairs)
+}
+
+// checkExplanationTable checks the explanation cache of a vocabulary table, given the ids of the words kept.
+// Explanations of missing or merged words, empty ones and duplicates are deleted.
+func (d *doctor) checkExplanationTable(table string, wordIDs []string) error {
+	if !d.backend.CheckTableExists(table) {
+		return nil
+	}
+	repairs, err := d.readTable(table, explanationColumns)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(repairs))
+	for i := range repairs {
+		record := repairs[i].repaired
+		key := strings.Join([]string{record["word_id"], record["model"], record["prompt_version"]}, "\x00")
+		switch {
+		case !slices.Contains(wordIDs, record["word_id"]):
+			d.report(table, record["id"], true, "explanation of missing word %q", record["word_id"])
+			repairs[i].repaired = nil
+		case strings.TrimSpace(record["explanation"]) == "":
+			d.report(table, record["id"], true, "empty explanation")
+			repairs[i].repaired = nil
+		case slices.Contains(keys, key):
+			d.report(table, record["id"], true, "duplicate explanation of %q for %s", record["word_id"], record["model"])
+			repairs[i].repaired = nil
+		default:
+			keys = append(keys, key)
+		}
+	}
+	d.checkIDs(table, repairs)
+
+	for _, r := range repairs {
+		if r.repaired == nil {
+			continue
+		}
+		d.checkRowTimestamps(table, r.repaired)
+	}
+
+	return d.apply(table, repairs)
+}
+
+// readTable checks the columns of the table against the schema, adding the missing ones when fixing,
+// and returns its rows to check.
+func (d *doctor) readTable(table string, schema []string) ([]rowRepair, error) {
+	if !d.backend.CheckTableExists(table) {
+		d.report(table, "", true, "table is missing")
+		if !d.fix {
+			return nil, nil
+		}
+		err := d.backend.CreateTable(table, schema)
+		if err != nil {
+			return nil, fmt.Errorf("error creating table %s: %w", table, err)
+		}
+	}
+
+	csvBackend, isCSV := d.backend.(*csvBackend)
+	if isCSV {
+		err := d.checkCSVRows(csvBackend.GetTablePath(table), table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	columns, err := d.backend.Columns(table)
+	if err != nil {
+		return nil, fmt.Errorf("error reading columns of %s: %w", table, err)
+	}
+	for i, column := range columns {
+		if slices.Contains(columns[:i], column) {
+			d.report(table, "", false, "duplicate column %s", column)
+		} else if !slices.Contains(schema, column) {
+			d.report(table, "", false, "unknown column %s", column)
+		}
+	}
+	for _, column := range schema {
+		if slices.Contains(columns, column) {
+			continue
+		}
+		d.report(table, "", true, "missing column %s", column)
+		if !d.fix {
+			continue
+		}
+		err := d.backend.AddColumn(table, column, columnDefaults[column])
+		if err != nil {
+			return nil, fmt.Errorf("error adding column %s to %s: %w", column, table, err)
+		}
+	}
+
+	var records []csvstore.CSVRecord
+	if isCSV {
+		// csvstore refuses to read a table with a row of the wrong length, which is only fixed with fix
+		t, err := readCSVTable(csvBackend.GetTablePath(table))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", table, err)
+		}
+		for _, row := range t.rows {
+			records = append(records, t.record(row))
+		}
+	} else {
+		qResult, err := d.backend.Query(table, []csvstore.QueryCondition{})
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", table, err)
+		}
+		records = qResult.Records
+	}
+	repairs := make([]rowRepair, 0, len(records))
+	for _, record := range records {
+		repairs = append(repairs, rowRepair{original: record, repaired: maps.Clone(record)})
+	}
+	return repairs, nil
+}
+
+// checkCSVRows reports the rows of a CSV table file with fewer or more fields than the header,
+// and pads them with empty values or trims the extra fields when fixing.
+func (d *doctor) checkCSVRows(path string, table string) error {
+	t, err := readRawCSVTable(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", table, err)
+	}
+	idIndex := slices.Index(t.headers, "id")
+	ragged := false
+	for i, row := range t.rows {
+		if len(row) == len(t.headers) {
+			continue
+		}
+		id := ""
+		if idIndex >= 0 && idIndex < len(row) {
+			id = row[idIndex]
+		}
+		d.report(table, id, true, "%d fields instead of %d", len(row), len(t.headers))
+		ragged = true
+		if len(row) < len(t.headers) {
+			t.rows[i] = append(row, make([]string, len(t.headers)-len(row))...)
+		} else {
+			t.rows[i] = row[:len(t.headers)]
+		}
+	}
+	if !ragged || !d.fix {
+		return nil
+	}
+	return t.write()
+}
+
+// apply writes the repaired rows of a table when fixing.
+// Every row sharing the id of a changed row is deleted and the rows kept are inserted again,
+// since rows with a duplicate id cannot be updated one at a time.
+func (d *doctor) apply(table string, repairs []rowRepair) error {
+	if !d.fix {
+		return nil
+	}
+
+	now := time.Now().Format(time.RFC3339Nano)
+	touched := make([]string, 0)
+	for _, r := range repairs {
+		if r.repaired != nil && maps.Equal(r.original, r.repaired) {
+			continue
+		}
+		if r.repaired != nil {
+			if _, ok := r.repaired["updated_at"]; ok {
+				r.repaired["updated_at"] = now
+			}
+		}
+		if !slices.Contains(touched, r.original["id"]) {
+			touched = append(touched, r.original["id"])
+		}
+	}
+
+	for _, id := range touched {
+		_, err := d.backend.Delete(table, []csvstore.QueryCondition{{
+			Column:   "id",
+			Operator: "=",
+			Value:    id,
+		}})
+		if err != nil {
+			return fmt.Errorf("error deleting rows of %s: %w", table, err)
+		}
+		for _, r := range repairs {
+			if r.original["id"] != id || r.repaired == nil {
+				continue
+			}
+			_, err := d.backend.Insert(table, r.repaired)
+			if err != nil {
+				return fmt.Errorf("error writing repaired row of %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkIDs gives a new id to the rows kept with a blank or duplicate id.
+func (d *doctor) checkIDs(table string, repairs []rowRepair) {
+	used := map[string]bool{}
+	for _, r := range repairs {
+		used[r.original["id"]] = true
+	}
+
+	seen := map[string]bool{}
+	for _, r := range repairs {
+		if r.repaired == nil {
+			continue
+		}
+		id, ok := r.repaired["id"]
+		if !ok {
+			return
+		}
+		if id != "" && !seen[id] {
+			seen[id] = true
+			continue
+		}
+		newID := time.Now().UnixNano()
+		for used[strconv.FormatInt(newID, 10)] {
+			newID++
+		}
+		r.repaired["id"] = strconv.FormatInt(newID, 10)
+		used[r.repaired["id"]] = true
+		seen[r.repaired["id"]] = true
+		if id == "" {
+			d.report(table, r.repaired["id"], true, "blank id")
+		} else {
+			d.report(table, r.repaired["id"], true, "duplicate id %s", id)
+		}
+	}
+}
+
+// checkInt checks that the column holds a non-negative integer.
+// An empty value is allowed when the fallback is empty. Values such as " 3" or "3.0" are repaired
+// and anything else is replaced with the fallback.
+func (d *doctor) checkInt(table string, record csvstore.CSVRecord, column string, fallback string) {
+	value, ok := record[column]
+	if !ok || (value == "" && fallback == "") {
+		return
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+		return
+	}
+
+	repaired := fallback
+	if f, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && f >= 0 && f == math.Trunc(f) {
+		repaired = strconv.Itoa(int(f))
+	}
+	d.report(table, record["id"], true, "%s is not a non-negative integer: %q", column, value)
+	record[column] = repaired
+}
+
+// checkEase checks that the ease is empty or a number the scheduler accepts.
+func (d *doctor) checkEase(table string, record csvstore.CSVRecord) {
+	value, ok := record["ease"]
+	if !ok || value == "" {
+		return
+	}
+	if ease, err := strconv.ParseFloat(value, 64); err == nil && ease >= minimumEase {
+		return
+	}
+	d.report(table, record["id"], true, "ease is not a number of at least %.1f: %q", minimumEase, value)
+	record["ease"] = ""
+}
+
+// checkRowTimestamps checks created_at and updated_at, which fall back on each other.
+func (d *doctor) checkRowTimestamps(table string, record csvstore.CSVRecord) {
+	fallback := time.Now().Format(time.RFC3339Nano)
+	if _, err := time.Parse(time.RFC3339Nano, record["updated_at"]); err == nil {
+		fallback = record["updated_at"]
+	}
+	d.checkTimestamp(table, record, "created_at", fallback)
+	d.checkTimestamp(table, record, "updated_at", record["created_at"])
+}
+
+// checkTimestamp checks that the column holds an RFC 3339 timestamp.
+// An empty value is allowed when the fallback is empty. Timestamps in other common layouts are
+// converted and anything else is replaced with the fallback.
+func (d *doctor) checkTimestamp(table string, record csvstore.CSVRecord, column string, fallback string) {
+	value, ok := record[column]
+	if !ok || (value == "" && fallback == "") {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return
+	}
+
+	repaired := fallback
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(value), time.Local); err == nil {
+			repaired = t.Format(time.RFC3339Nano)
+			break
+		}
+	}
+	d.report(table, record["id"], true, "%s is not a timestamp: %q", column, value)
+	record[column] = repaired
+}
+
+// checkStatus checks that the status is one of the statuses, making words with any other status active.
+func (d *doctor) checkStatus(table string, record csvstore.CSVRecord) {
+	value, ok := record["status"]
+	if !ok {
+		return
+	}
+	if _, err := ParseStatus(value); err == nil {
+		return
+	}
+
+	repaired := StatusActive
+	if status, err := ParseStatus(normalizeWord(value)); err == nil {
+		repaired = status
+	}
+	d.report(table, record["id"], true, "unknown status %q", value)
+	record["status"] = string(repaired)
+}
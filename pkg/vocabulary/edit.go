@@ -0,0 +1,199 @@
+package vocabulary
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// RenameVocabulary changes the word of an entry in place, keeping its history.
+// Cached explanations are dropped, since they explain the old word.
+func (s *store) RenameVocabulary(oldWord string, newWord string) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	record, err := s.FindVocabulary(oldWord)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("vocabulary not found: %s", oldWord)
+	}
+	lowercaseWord := normalizeWord(newWord)
+	if lowercaseWord == "" {
+		return nil, fmt.Errorf("new word cannot be empty")
+	}
+	existing, err := s.FindVocabulary(lowercaseWord)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("vocabulary already exists: %s (merge the entries instead)", newWord)
+	}
+
+	uResult, err := backend.Update(s.opts.TableName, csvstore.CSVRecord{
+		"word": lowercaseWord,
+	}, []csvstore.QueryCondition{
+		{
+			Column:   "id",
+			Operator: "=",
+			Value:    record["id"],
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error renaming vocabulary: %w", err)
+	}
+	err = s.deleteExplanations(backend, record["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := s.syncStore("edit", fmt.Sprintf("rename %q to %q", record["word"], lowercaseWord))
+		if err != nil {
+			log.Printf("error syncing store: %v\n", err)
+		}
+	}()
+
+	return uResult.Records[0], nil
+}
+
+// MergeVocabulary merges the entry of word from into the entry of word into and deletes it.
+// Read counts and lapses are summed, the earlier created_at is kept,
+// and context, source, note and tags are unioned.
+func (s *store) MergeVocabulary(into string, from string) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	target, err := s.FindVocabulary(into)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("vocabulary not found: %s", into)
+	}
+	source, err := s.FindVocabulary(from)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, fmt.Errorf("vocabulary not found: %s", from)
+	}
+	if target["id"] == source["id"] {
+		return nil, fmt.Errorf("cannot merge a vocabulary into itself: %s", into)
+	}
+
+	uResult, err := backend.Update(s.opts.TableName, mergeRecords(target, source), []csvstore.QueryCondition{
+		{
+			Column:   "id",
+			Operator: "=",
+			Value:    target["id"],
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating merged vocabulary: %w", err)
+	}
+	_, err = backend.Delete(s.opts.TableName, []csvstore.QueryCondition{
+		{
+			Column:   "id",
+			Operator: "=",
+			Value:    source["id"],
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error deleting merged vocabulary: %w", err)
+	}
+	err = s.moveReviews(backend, source["id"], target["id"])
+	if err != nil {
+		return nil, err
+	}
+	err = s.deleteExplanations(backend, source["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := s.syncStore("merge", fmt.Sprintf("merge %q into %q", source["word"], target["word"]))
+		if err != nil {
+			log.Printf("error syncing store: %v\n", err)
+		}
+	}()
+
+	return uResult.Records[0], nil
+}
+
+// mergeRecords returns the updates that fold source into target.
+// The schedule of the entry due sooner is kept, since that is the one remembered less well.
+func mergeRecords(target, source csvstore.CSVRecord) csvstore.CSVRecord {
+	updates := csvstore.CSVRecord{
+		"read_count": strconv.Itoa(atoiOrZero(target["read_count"]) + atoiOrZero(source["read_count"])),
+		"context":    unionText(target["context"], source["context"]),
+		"source":     unionText(target["source"], source["source"]),
+		"note":       unionText(target["note"], source["note"]),
+		"tags":       formatTags(append(parseTags(target["tags"]), parseTags(source["tags"])...)),
+		"deck":       target["deck"],
+		"created_at": target["created_at"],
+	}
+	if updates["deck"] == "" {
+		updates["deck"] = source["deck"]
+	}
+	if compareTimestamps(source["created_at"], target["created_at"]) < 0 {
+		updates["created_at"] = source["created_at"]
+	}
+
+	targetSchedule := parseSchedule(target)
+	sourceSchedule := parseSchedule(source)
+	merged := targetSchedule
+	if sourceSchedule.dueAt.Before(targetSchedule.dueAt) {
+		merged = sourceSchedule
+	}
+	merged.lapses = targetSchedule.lapses + sourceSchedule.lapses
+	if target["due_at"] != "" || source["due_at"] != "" {
+		for column, value := range merged.toRecord() {
+			updates[column] = value
+		}
+	}
+	return updates
+}
+
+// unionText joins the distinct non-empty values of a free-text column.
+func unionText(values ...string) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, "; ") {
+			part = strings.TrimSpace(part)
+			if part != "" && !slices.Contains(parts, part) {
+				parts = append(parts, part)
+			}
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func atoiOrZero(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return n
+}
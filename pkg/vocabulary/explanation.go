@@ -0,0 +1,133 @@
+package vocabulary
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// explanationColumns are the columns of the explanation cache table.
+// An explanation is cached per word, model and prompt version, so that changing the model
+// or the prompt (including the context of the word) asks for a new one.
+var explanationColumns = []string{"id", "word_id", "model", "prompt_version", "explanation", "created_at", "updated_at"}
+
+// explanationsTableName returns the name of the explanation cache table of a vocabulary table,
+// such as eng__voca_explanations for eng__voca.
+func explanationsTableName(tableName string) string {
+	return tableName + "_explanations"
+}
+
+// CachedExplanation returns the explanation of the word cached for the model and prompt version,
+// or an empty string when there is none.
+func (s *store) CachedExplanation(wordID string, model string, promptVersion string) (string, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return "", fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	qResult, err := backend.Query(explanationsTableName(s.opts.TableName), explanationConditions(wordID, model, promptVersion))
+	if err != nil {
+		return "", fmt.Errorf("error querying explanations: %w", err)
+	}
+	if qResult.Count == 0 {
+		return "", nil
+	}
+	return qResult.Records[0]["explanation"], nil
+}
+
+// SaveExplanation caches the explanation of the word for the model and prompt version,
+// replacing the one cached before.
+func (s *store) SaveExplanation(wordID string, model string, promptVersion string, explanation string) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	qResult, err := backend.Query(s.opts.TableName, []csvstore.QueryCondition{{
+		Column:   "id",
+		Operator: "=",
+		Value:    wordID,
+	}})
+	if err != nil {
+		return fmt.Errorf("error while checking existing vocabulary: %w", err)
+	}
+	if qResult.Count == 0 {
+		return fmt.Errorf("vocabulary not found: %s", wordID)
+	}
+	record := qResult.Records[0]
+
+	table := explanationsTableName(s.opts.TableName)
+	conditions := explanationConditions(wordID, model, promptVersion)
+	uResult, err := backend.Update(table, csvstore.CSVRecord{"explanation": explanation}, conditions)
+	if err != nil {
+		return fmt.Errorf("error updating explanation: %w", err)
+	}
+	if uResult.Count == 0 {
+		_, err = backend.Insert(table, csvstore.CSVRecord{
+			"word_id":        wordID,
+			"model":          model,
+			"prompt_version": promptVersion,
+			"explanation":    explanation,
+		})
+		if err != nil {
+			return fmt.Errorf("error saving explanation: %w", err)
+		}
+	}
+
+	defer func() {
+		err := s.syncStore("explain", fmt.Sprintf("explain %q", record["word"]))
+		if err != nil {
+			log.Printf("error syncing store: %v\n", err)
+		}
+	}()
+
+	return nil
+}
+
+func explanationConditions(wordID string, model string, promptVersion string) []csvstore.QueryCondition {
+	return []csvstore.QueryCondition{
+		{Column: "word_id", Operator: "=", Value: wordID},
+		{Column: "model", Operator: "=", Value: model},
+		{Column: "prompt_version", Operator: "=", Value: promptVersion},
+	}
+}
+
+// getExplanations returns the most recently updated explanation of every word, by word id.
+func (s *store) getExplanations(backend Backend) (map[string]string, error) {
+	qResult, err := backend.Query(explanationsTableName(s.opts.TableName), []csvstore.QueryCondition{})
+	if err != nil {
+		return nil, fmt.Errorf("error querying explanations: %w", err)
+	}
+	explanations := make(map[string]string, qResult.Count)
+	updatedAt := make(map[string]string, qResult.Count)
+	for _, record := range qResult.Records {
+		wordID := record["word_id"]
+		if _, ok := explanations[wordID]; ok && compareTimestamps(record["updated_at"], updatedAt[wordID]) < 0 {
+			continue
+		}
+		explanations[wordID] = record["explanation"]
+		updatedAt[wordID] = record["updated_at"]
+	}
+	return explanations, nil
+}
+
+// deleteExplanations removes the cached explanations of a word that was deleted, renamed or merged,
+// since they explain a word that is no longer in the store.
+func (s *store) deleteExplanations(backend Backend, wordID string) error {
+	_, err := backend.Delete(explanationsTableName(s.opts.TableName), []csvstore.QueryCondition{{
+		Column:   "word_id",
+		Operator: "=",
+		Value:    wordID,
+	}})
+	if err != nil {
+		return fmt.Errorf("error deleting explanations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package vocabulary
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+	"github.com/yuin/goldmark"
+)
+
+// ExportFormat is a file format the vocabulary can be exported to.
+type ExportFormat string
+
+const (
+	// ExportFormatAnki is a tab-separated text file for Anki's "Import File" (front, back, tags).
+	ExportFormatAnki ExportFormat = "anki"
+	// ExportFormatQuizlet is the "term<TAB>definition" text accepted by Quizlet's import.
+	ExportFormatQuizlet  ExportFormat = "quizlet"
+	ExportFormatJSON     ExportFormat = "json"
+	ExportFormatMarkdown ExportFormat = "md"
+)
+
+var exportFormats = []ExportFormat{
+	ExportFormatAnki,
+	ExportFormatQuizlet,
+	ExportFormatJSON,
+	ExportFormatMarkdown,
+}
+
+// ParseExportFormat validates the name of an export format.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	format := ExportFormat(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(exportFormats, format) {
+		return "", fmt.Errorf("unsupported export format: %s (expected anki, quizlet, json or md)", name)
+	}
+	return format, nil
+}
+
+// exportEntry is a vocabulary record in the shape it is exported in.
+type exportEntry struct {
+	Word      string   `json:"word"`
+	Context   string   `json:"context,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	Tags      []string `json:"tags"`
+	Deck      string   `json:"deck,omitempty"`
+	Status    string   `json:"status"`
+	ReadCount int      `json:"read_count"`
+	DueAt     string   `json:"due_at,omitempty"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+	// Explanation is the cached AI explanation of the word in Markdown.
+	Explanation string `json:"explanation,omitempty"`
+}
+
+func newExportEntry(record csvstore.CSVRecord, explanation string) exportEntry {
+	readCount, _ := strconv.Atoi(record["read_count"])
+	return exportEntry{
+		Word:      record["word"],
+		Context:   record["context"],
+		Source:    record["source"],
+		Note:      record["note"],
+		Tags:      parseTags(record["tags"]),
+		Deck:      record["deck"],
+		Status:    record["status"],
+		ReadCount: readCount,
+		DueAt:     record["due_at"],
+		CreatedAt: record["created_at"],
+		UpdatedAt: record["updated_at"],
+
+		Explanation: explanation,
+	}
+}
+
+// ExportVocabulary writes the words matching the filter to w, oldest first.
+// Cached explanations are included in every format but Quizlet, whose definitions are a single line.
+func (s *store) ExportVocabulary(w io.Writer, format ExportFormat, filter Filter) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	records, err := s.queryVocabulary(backend, filter)
+	if err != nil {
+		return err
+	}
+	explanations, err := s.getExplanations(backend)
+	if err != nil {
+		return err
+	}
+	entries := make([]exportEntry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, newExportEntry(record, explanations[record["id"]]))
+	}
+	slices.SortStableFunc(entries, func(a, b exportEntry) int {
+		return compareTimestamps(a.CreatedAt, b.CreatedAt)
+	})
+
+	switch format {
+	case ExportFormatAnki:
+		return exportAnki(w, entries)
+	case ExportFormatQuizlet:
+		return exportQuizlet(w, entries)
+	case ExportFormatJSON:
+		return exportJSON(w, entries)
+	case ExportFormatMarkdown:
+		return exportMarkdown(w, entries)
+	}
+	return fmt.Errorf("unsupported export format: %s", format)
+}
+
+func exportAnki(w io.Writer, entries []exportEntry) error {
+	_, err := io.WriteString(w, "#separator:tab\n#html:true\n#tags column:3\n")
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, e := range entries {
+		back := make([]string, 0, 4)
+		if e.Context != "" {
+			back = append(back, "<i>"+html.EscapeString(e.Context)+"</i>")
+		}
+		if e.Note != "" {
+			back = append(back, html.EscapeString(e.Note))
+		}
+		if e.Source != "" {
+			back = append(back, "<small>"+html.EscapeString(e.Source)+"</small>")
+		}
+		if e.Explanation != "" {
+			var sb strings.Builder
+			err := goldmark.Convert([]byte(e.Explanation), &sb)
+			if err != nil {
+				return fmt.Errorf("failed to convert explanation: %w", err)
+			}
+			back = append(back, sb.String())
+		}
+		tags := slices.Clone(e.Tags)
+		if e.Deck != "" {
+			tags = append(tags, "deck::"+e.Deck)
+		}
+		_, err := fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\n",
+			singleLine(html.EscapeString(e.Word)),
+			singleLine(strings.Join(back, "<br>")),
+			strings.Join(tags, " "),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func exportQuizlet(w io.Writer, entries []exportEntry) error {
+	for _, e := range entries {
+		definition := make([]string, 0, 2)
+		if e.Context != "" {
+			definition = append(definition, e.Context)
+		}
+		if e.Note != "" {
+			definition = append(definition, e.Note)
+		}
+		_, err := fmt.Fprintf(
+			w,
+			"%s\t%s\n",
+			singleLine(e.Word),
+			singleLine(strings.Join(definition, " / ")),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write entry: %w", err)
+		}
+	}
+	return nil
+}
+
+func exportJSON(w io.Writer, entries []exportEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode entries: %w", err)
+	}
+	return nil
+}
+
+func exportMarkdown(w io.Writer, entries []exportEntry) error {
+	var sb strings.Builder
+	sb.WriteString("# Vocabulary\n")
+	for _, e := range entries {
+		sb.WriteString("\n## " + e.Word + "\n\n")
+		if e.Context != "" {
+			sb.WriteString("> " + singleLine(e.Context) + "\n\n")
+		}
+		if e.Source != "" {
+			sb.WriteString("- Source: " + e.Source + "\n")
+		}
+		if e.Note != "" {
+			sb.WriteString("- Note: " + singleLine(e.Note) + "\n")
+		}
+		if len(e.Tags) > 0 {
+			sb.WriteString("- Tags: " + strings.Join(e.Tags, ", ") + "\n")
+		}
+		if e.Deck != "" {
+			sb.WriteString("- Deck: " + e.Deck + "\n")
+		}
+		sb.WriteString("- Added: " + formatDate(e.CreatedAt) + "\n")
+		if e.Explanation != "" {
+			sb.WriteString("\n" + nestHeadings(e.Explanation, 2) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	if err != nil {
+		return fmt.Errorf("failed to write markdown: %w", err)
+	}
+	return nil
+}
+
+// nestHeadings moves the Markdown headings of text down by levels,
+// so that an explanation fits under the heading of its word.
+func nestHeadings(text string, levels int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			lines[i] = strings.Repeat("#", levels) + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// singleLine replaces line breaks and tabs, which separate fields and rows in line-based formats.
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// formatDate shortens an RFC 3339 timestamp to its date.
+func formatDate(timestamp string) string {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return t.Format("2006-01-02")
+}
+
+// compareTimestamps orders RFC 3339 timestamps in time, putting unparsable ones last.
+func compareTimestamps(a, b string) int {
+	ta, errA := time.Parse(time.RFC3339Nano, a)
+	tb, errB := time.Parse(time.RFC3339Nano, b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	}
+	return ta.Compare(tb)
+}
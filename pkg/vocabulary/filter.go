@@ -0,0 +1,124 @@
+package vocabulary
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// Filter narrows down the vocabulary a command works on.
+// The zero value matches every word.
+type Filter struct {
+	// Tags lists the tags a word must all have.
+	Tags []string
+	// Deck is the deck a word must belong to.
+	Deck string
+	// Status is the status a word must have.
+	Status Status
+	// CreatedSince excludes words added before it when not zero.
+	CreatedSince time.Time
+	// CreatedBefore excludes words added at or after it when not zero.
+	CreatedBefore time.Time
+	// Contains is a case-insensitive substring the word must contain.
+	Contains string
+	// Pattern is a regular expression the word must match.
+	Pattern *regexp.Regexp
+	// MinReadCount and MaxReadCount bound read_count inclusively when not nil.
+	MinReadCount *int
+	MaxReadCount *int
+}
+
+// conditions returns the part of the filter that csvstore can evaluate.
+func (f Filter) conditions() []csvstore.QueryCondition {
+	conditions := []csvstore.QueryCondition{
+		{
+			Column:   "word",
+			Operator: "!=",
+			Value:    "",
+		},
+	}
+	if f.Deck != "" {
+		conditions = append(conditions, csvstore.QueryCondition{
+			Column:   "deck",
+			Operator: "=",
+			Value:    normalizeTag(f.Deck),
+		})
+	}
+	if f.Status != "" {
+		conditions = append(conditions, csvstore.QueryCondition{
+			Column:   "status",
+			Operator: "=",
+			Value:    string(f.Status),
+		})
+	}
+	if f.Contains != "" {
+		conditions = append(conditions, csvstore.QueryCondition{
+			Column:   "word",
+			Operator: "contains",
+			Value:    f.Contains,
+		})
+	}
+	if f.MinReadCount != nil {
+		conditions = append(conditions, csvstore.QueryCondition{
+			Column:   "read_count",
+			Operator: ">=",
+			Value:    strconv.Itoa(*f.MinReadCount),
+		})
+	}
+	if f.MaxReadCount != nil {
+		conditions = append(conditions, csvstore.QueryCondition{
+			Column:   "read_count",
+			Operator: "<=",
+			Value:    strconv.Itoa(*f.MaxReadCount),
+		})
+	}
+	return conditions
+}
+
+// matches evaluates the part of the filter that the backend cannot, on a queried record.
+func (f Filter) matches(record csvstore.CSVRecord) bool {
+	if f.Pattern != nil && !f.Pattern.MatchString(record["word"]) {
+		return false
+	}
+
+	tags := parseTags(record["tags"])
+	for _, tag := range normalizeTags(f.Tags) {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+
+	// timestamps are compared as times since rows may be written in different time zones
+	if !f.CreatedSince.IsZero() || !f.CreatedBefore.IsZero() {
+		createdAt, err := time.Parse(time.RFC3339Nano, record["created_at"])
+		if err != nil {
+			return false
+		}
+		if !f.CreatedSince.IsZero() && createdAt.Before(f.CreatedSince) {
+			return false
+		}
+		if !f.CreatedBefore.IsZero() && !createdAt.Before(f.CreatedBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryVocabulary returns the vocabulary records matching the filter.
+func (s *store) queryVocabulary(backend Backend, filter Filter) ([]csvstore.CSVRecord, error) {
+	qResult, err := backend.Query(s.opts.TableName, filter.conditions())
+	if err != nil {
+		return nil, fmt.Errorf("error getting vocabulary: %w", err)
+	}
+	records := make([]csvstore.CSVRecord, 0, qResult.Count)
+	for _, record := range qResult.Records {
+		if filter.matches(record) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,165 @@
+package vocabulary
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// historyEntry is a commit of the store made by voca.
+type historyEntry struct {
+	hash      string
+	date      time.Time
+	operation string
+	subject   string
+}
+
+// getHistory returns up to limit of the most recent voca commits, newest first,
+// or all of them when limit is 0. Commits not made by voca are skipped.
+func (s *store) getHistory(limit int) ([]historyEntry, error) {
+	output, err := s.git(
+		"log",
+		"--date=iso-strict",
+		fmt.Sprintf(
+			"--format=%%h%%x1f%%ad%%x1f%%s%%x1f%%(trailers:key=%s,valueonly,separator=%%x2c)%%x1e",
+			vocaOperationTrailer,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []historyEntry{}
+	for commit := range strings.SplitSeq(output, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(commit), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing commit date %q: %w", fields[1], err)
+		}
+		e := historyEntry{
+			hash:      fields[0],
+			date:      date,
+			operation: strings.TrimSpace(fields[3]),
+			subject:   fields[2],
+		}
+		if e.operation == "" && strings.HasPrefix(e.subject, legacyCommitPrefix) {
+			e.operation = "sync"
+		}
+		if e.operation == "" {
+			continue
+		}
+		entries = append(entries, e)
+		if len(entries) == limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// ListHistory lists the most recent operations recorded in the store.
+func (s *store) ListHistory(limit int) error {
+	if s.opts.StorageMode != StorageModeGit {
+		return fmt.Errorf("history requires the git storage mode")
+	}
+	_, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	entries, err := s.getHistory(limit)
+	if err != nil {
+		return fmt.Errorf("error getting history: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no history found")
+	}
+
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	_, err = writer.Write([]byte("Commit\tDate\tOperation\tSummary\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, e := range entries {
+		_, err := fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\n",
+			e.hash,
+			e.date.Local().Format("2006-01-02 15:04"),
+			e.operation,
+			strings.TrimPrefix(e.subject, "chore: "),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write history data: %w", err)
+		}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
+
+// Undo reverts the most recent commit of the store and syncs the revert.
+// It refuses when the working tree has uncommitted changes or the commit was not made by voca.
+// Like every other operation it works offline: the revert is committed locally and pushed later.
+func (s *store) Undo() (string, error) {
+	if s.opts.StorageMode != StorageModeGit {
+		return "", fmt.Errorf("undo requires the git storage mode")
+	}
+	_, err := s.getBackend()
+	if err != nil {
+		return "", fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	if !s.checkIsGitRepo() {
+		return "", fmt.Errorf("store is not a git repository")
+	}
+
+	status, err := s.git("status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+	if status != "" {
+		return "", fmt.Errorf("store has uncommitted changes:\n%s", status)
+	}
+	head, err := s.git("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	entries, err := s.getHistory(1)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 || entries[0].hash != head {
+		return "", fmt.Errorf("the last commit %s was not made by voca", head)
+	}
+	last := entries[0]
+	if last.operation == pullOperation {
+		return "", fmt.Errorf("the last commit %s merged remote changes and cannot be undone", head)
+	}
+
+	_, err = s.git("revert", "--no-commit", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	summary := strings.TrimPrefix(last.subject, "chore: ")
+	err = s.syncStore("undo", fmt.Sprintf("undo %s (%s)", summary, last.hash))
+	if err != nil {
+		return "", err
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,274 @@
+package vocabulary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// jsonFileName is the file of the JSON backend in the store directory.
+var jsonFileName = "voca.json"
+
+// jsonTable is a table of the JSON backend.
+// Every row has a value for every column so rows read back exactly as csvstore reads them.
+type jsonTable struct {
+	Columns []string             `json:"columns"`
+	Rows    []csvstore.CSVRecord `json:"rows"`
+}
+
+// jsonBackend keeps every table in a single, indented JSON file so that it diffs well in git.
+// The file is read on every call and rewritten on every change, like the CSV files of csvstore.
+type jsonBackend struct {
+	path string
+}
+
+func newJSONBackend(dir string) (*jsonBackend, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &jsonBackend{path: filepath.Join(dir, jsonFileName)}, nil
+}
+
+// csvTable returns the table as a CSV table, so that it can be merged like one.
+// A nil table is a table that does not exist.
+func (t *jsonTable) csvTable() *csvTable {
+	if t == nil {
+		return &csvTable{}
+	}
+	table := &csvTable{headers: t.Columns}
+	for _, record := range t.Rows {
+		row := make([]string, len(t.Columns))
+		for i, column := range t.Columns {
+			row[i] = record[column]
+		}
+		table.rows = append(table.rows, row)
+	}
+	return table
+}
+
+func (b *jsonBackend) load() (map[string]*jsonTable, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]*jsonTable{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", b.path, err)
+	}
+	tables := map[string]*jsonTable{}
+	err = json.Unmarshal(data, &tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", b.path, err)
+	}
+	return tables, nil
+}
+
+func (b *jsonBackend) save(tables map[string]*jsonTable) error {
+	data, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tables: %w", err)
+	}
+	// write to a temporary file first so a crash never leaves a truncated store
+	tmpPath := b.path + ".tmp"
+	err = os.WriteFile(tmpPath, append(data, '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+	err = os.Rename(tmpPath, b.path)
+	if err != nil {
+		return fmt.Errorf("failed to replace %s: %w", b.path, err)
+	}
+	return nil
+}
+
+// loadTable loads every table along with the named one, which must exist.
+func (b *jsonBackend) loadTable(tableName string) (map[string]*jsonTable, *jsonTable, error) {
+	tables, err := b.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	t, ok := tables[tableName]
+	if !ok {
+		return nil, nil, fmt.Errorf("table %s does not exist", tableName)
+	}
+	return tables, t, nil
+}
+
+func (b *jsonBackend) CheckTableExists(tableName string) bool {
+	tables, err := b.load()
+	if err != nil {
+		return false
+	}
+	_, ok := tables[tableName]
+	return ok
+}
+
+func (b *jsonBackend) CreateTable(tableName string, columns []string) error {
+	tables, err := b.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := tables[tableName]; ok {
+		return fmt.Errorf("table %s already exists", tableName)
+	}
+	tables[tableName] = &jsonTable{Columns: slices.Clone(columns), Rows: []csvstore.CSVRecord{}}
+	return b.save(tables)
+}
+
+func (b *jsonBackend) Columns(tableName string) ([]string, error) {
+	_, t, err := b.loadTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return t.Columns, nil
+}
+
+func (b *jsonBackend) AddColumn(tableName, column, defaultValue string) error {
+	tables, t, err := b.loadTable(tableName)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(t.Columns, column) {
+		return fmt.Errorf("column %s already exists in %s", column, tableName)
+	}
+	t.Columns = append(t.Columns, column)
+	for _, row := range t.Rows {
+		row[column] = defaultValue
+	}
+	return b.save(tables)
+}
+
+func (b *jsonBackend) RenameColumn(tableName, oldColumn, newColumn string) error {
+	tables, t, err := b.loadTable(tableName)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(t.Columns, newColumn) {
+		return fmt.Errorf("column %s already exists in %s", newColumn, tableName)
+	}
+	i := slices.Index(t.Columns, oldColumn)
+	if i < 0 {
+		return fmt.Errorf("column %s not found in %s", oldColumn, tableName)
+	}
+	t.Columns[i] = newColumn
+	for _, row := range t.Rows {
+		row[newColumn] = row[oldColumn]
+		delete(row, oldColumn)
+	}
+	return b.save(tables)
+}
+
+func (b *jsonBackend) FillColumn(tableName, column string, value func(record csvstore.CSVRecord) string) error {
+	tables, t, err := b.loadTable(tableName)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(t.Columns, column) {
+		return fmt.Errorf("column %s not found in %s", column, tableName)
+	}
+	for _, row := range t.Rows {
+		row[column] = value(maps.Clone(row))
+	}
+	return b.save(tables)
+}
+
+func (b *jsonBackend) Insert(tableName string, record csvstore.CSVRecord) (csvstore.CSVRecord, error) {
+	tables, t, err := b.loadTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	row := newRow(t.Columns, record)
+	t.Rows = append(t.Rows, row)
+	err = b.save(tables)
+	if err != nil {
+		return nil, err
+	}
+	return maps.Clone(row), nil
+}
+
+func (b *jsonBackend) Query(
+	tableName string,
+	conditions []csvstore.QueryCondition,
+) (*csvstore.QueryResult, error) {
+	_, t, err := b.loadTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]csvstore.CSVRecord, 0)
+	for _, row := range t.Rows {
+		if matchesConditions(row, conditions) {
+			records = append(records, row)
+		}
+	}
+	return &csvstore.QueryResult{Records: records, Count: len(records)}, nil
+}
+
+func (b *jsonBackend) QuerySortedRange(
+	tableName string,
+	sortField string,
+	sortBy string,
+	limit int,
+) (*csvstore.QueryResult, error) {
+	_, t, err := b.loadTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return sortedRange(tableName, t.Rows, sortField, sortBy, limit)
+}
+
+func (b *jsonBackend) Update(
+	tableName string,
+	updates csvstore.CSVRecord,
+	conditions []csvstore.QueryCondition,
+) (*csvstore.QueryResult, error) {
+	tables, t, err := b.loadTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]csvstore.CSVRecord, 0)
+	for i, row := range t.Rows {
+		if matchesConditions(row, conditions) {
+			t.Rows[i] = updateRow(t.Columns, row, updates)
+			records = append(records, maps.Clone(t.Rows[i]))
+		}
+	}
+	if len(records) > 0 {
+		err = b.save(tables)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &csvstore.QueryResult{Records: records, Count: len(records)}, nil
+}
+
+func (b *jsonBackend) Delete(
+	tableName string,
+	conditions []csvstore.QueryCondition,
+) (*csvstore.QueryResult, error) {
+	tables, t, err := b.loadTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]csvstore.CSVRecord, 0)
+	t.Rows = slices.DeleteFunc(t.Rows, func(row csvstore.CSVRecord) bool {
+		if matchesConditions(row, conditions) {
+			records = append(records, row)
+			return true
+		}
+		return false
+	})
+	if len(records) > 0 {
+		err = b.save(tables)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &csvstore.QueryResult{Records: records, Count: len(records)}, nil
+}
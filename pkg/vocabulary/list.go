@@ -0,0 +1,100 @@
+package vocabulary
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// ListQuery selects, orders and paginates the words to list.
+type ListQuery struct {
+	Filter
+	// SortBy is the column to sort by. Words are sorted by created_at when empty.
+	SortBy     string
+	Descending bool
+	// Page is 1-based.
+	Page int
+	// PerPage is the number of words per page. Every word is listed when it is 0.
+	PerPage int
+}
+
+// ListVocabulary lists the words matching the query as a table.
+func (s *store) ListVocabulary(q ListQuery) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	records, err := s.queryVocabulary(backend, q.Filter)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no vocabulary found")
+	}
+
+	sortBy := q.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if _, ok := records[0][sortBy]; !ok {
+		return fmt.Errorf("unknown column to sort by: %s", sortBy)
+	}
+	slices.SortStableFunc(records, func(a, b csvstore.CSVRecord) int {
+		result := compareValues(a[sortBy], b[sortBy])
+		if q.Descending {
+			result = -result
+		}
+		return result
+	})
+
+	total := len(records)
+	page, pageCount := 1, 1
+	if q.PerPage > 0 {
+		pageCount = (total + q.PerPage - 1) / q.PerPage
+		page = max(q.Page, 1)
+		if page > pageCount {
+			return fmt.Errorf("page %d is out of range (1-%d)", page, pageCount)
+		}
+		records = records[(page-1)*q.PerPage : min(page*q.PerPage, total)]
+	}
+
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	_, err = writer.Write([]byte("Word\tStatus\tRead\tDue\tTags\tDeck\tAdded\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, record := range records {
+		_, err := fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			record["word"],
+			record["status"],
+			record["read_count"],
+			formatDate(record["due_at"]),
+			strings.ReplaceAll(record["tags"], ",", ", "),
+			record["deck"],
+			formatDate(record["created_at"]),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write vocabulary data: %w", err)
+		}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+
+	if q.PerPage > 0 {
+		fmt.Printf("\nPage %d/%d (%d words)\n", page, pageCount, total)
+	} else {
+		fmt.Printf("\n%d words\n", total)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package vocabulary
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockTimeout is how long a voca process waits for another one to finish with the store.
+var lockTimeout = 10 * time.Second
+
+// lockRetryInterval is how often a locked store is checked again.
+var lockRetryInterval = 50 * time.Millisecond
+
+// errLocked is returned by tryLockFile when another process holds the lock.
+var errLocked = errors.New("locked by another process")
+
+// lockPath returns the lock file of a store directory.
+// It sits next to the directory so that it is never committed and can be taken before cloning.
+func lockPath(storePath string) string {
+	return strings.TrimRight(storePath, string(os.PathSeparator)) + ".lock"
+}
+
+// lockFile takes the advisory lock of the file, waiting up to lockTimeout for other processes.
+// The returned function releases the lock.
+func lockFile(path string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating lock directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err = tryLockFile(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) {
+			file.Close()
+			return nil, fmt.Errorf("error locking store: %w", err)
+		}
+		if time.Now().After(deadline) {
+			holder := "another voca process"
+			if pid, err := readLockHolder(path); err == nil {
+				holder = fmt.Sprintf("voca process %d", pid)
+			}
+			file.Close()
+			return nil, fmt.Errorf(
+				"store is locked by %s, gave up after %s (lock file: %s)",
+				holder,
+				lockTimeout,
+				path,
+			)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	// record the holder so that a waiting process can tell who it is waiting for
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+
+	return func() {
+		file.Truncate(0)
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
+
+func readLockHolder(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// lock takes the lock of the store for a mutation and the sync that follows it.
+// The store must be initialized. Nested calls within the process share the lock.
+func (s *store) lock() (func(), error) {
+	if s.lockDepth > 0 {
+		s.lockDepth++
+		return s.release, nil
+	}
+	unlock, err := lockFile(lockPath(s.storePath))
+	if err != nil {
+		return nil, err
+	}
+	s.lockDepth = 1
+	s.unlock = unlock
+
+	// the first mutation of a transaction keeps the lock until the transaction ends
+	if s.tx != nil && s.tx.snapshot == nil {
+		snapshot, err := readStoreFiles(s.storePath)
+		if err != nil {
+			s.release()
+			return nil, err
+		}
+		s.tx.snapshot = snapshot
+		s.lockDepth++
+	}
+	return s.release, nil
+}
+
+func (s *store) release() {
+	s.lockDepth--
+	if s.lockDepth == 0 {
+		s.unlock()
+		s.unlock = nil
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package vocabulary
+
+import "os"
+
+// Advisory locks are only supported on unix; elsewhere concurrent processes are not guarded.
+
+func tryLockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
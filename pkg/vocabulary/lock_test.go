@@ -0,0 +1,114 @@
+//go:build unix
+
+package vocabulary
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writerDirEnv and writerWordEnv make the test binary add a single word to a store and exit,
+// so that TestConcurrentAddVocabulary can run its writers as separate processes.
+const (
+	writerDirEnv  = "VOCA_TEST_WRITER_DIR"
+	writerWordEnv = "VOCA_TEST_WRITER_WORD"
+)
+
+func TestConcurrentAddVocabulary(t *testing.T) {
+	if dir := os.Getenv(writerDirEnv); dir != "" {
+		_, err := newLocalStore(dir).AddVocabulary(os.Getenv(writerWordEnv), AddOptions{Force: true})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	const writers = 20
+	dir := t.TempDir()
+
+	// every writer is a separate process, as concurrent voca commands would be
+	cmds := make([]*exec.Cmd, writers)
+	outputs := make([]bytes.Buffer, writers)
+	for i := range writers {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestConcurrentAddVocabulary$")
+		cmd.Env = append(os.Environ(), writerDirEnv+"="+dir, fmt.Sprintf("%s=word %d", writerWordEnv, i))
+		cmd.Stdout = &outputs[i]
+		cmd.Stderr = &outputs[i]
+		err := cmd.Start()
+		if err != nil {
+			t.Fatalf("starting writer %d: %v", i, err)
+		}
+		cmds[i] = cmd
+	}
+	for i, cmd := range cmds {
+		err := cmd.Wait()
+		if err != nil {
+			t.Errorf("writer %d: %v\n%s", i, err, outputs[i].String())
+		}
+	}
+
+	file, err := os.Open(filepath.Join(dir, defaultTableName+".csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("table is no longer valid CSV: %v", err)
+	}
+	if len(rows)-1 != writers {
+		t.Fatalf("got %d rows, want %d", len(rows)-1, writers)
+	}
+	seen := make(map[string]bool, writers)
+	for _, row := range rows[1:] {
+		seen[row[1]] = true
+	}
+	for i := range writers {
+		if word := fmt.Sprintf("word %d", i); !seen[word] {
+			t.Errorf("%q was lost", word)
+		}
+	}
+}
+
+func TestLockTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { lockTimeout = timeout }(lockTimeout)
+	lockTimeout = 100 * time.Millisecond
+
+	dir := t.TempDir()
+	s := newLocalStore(dir)
+	_, err := s.getBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockFile(lockPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = s.AddVocabulary("serendipity", AddOptions{})
+	unlock()
+	if err == nil {
+		t.Fatal("AddVocabulary succeeded while the store was locked")
+	}
+	if !strings.Contains(err.Error(), "store is locked") {
+		t.Errorf("error = %v, want a locked store error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("gave up after %s, want about %s", elapsed, lockTimeout)
+	}
+
+	_, err = s.AddVocabulary("serendipity", AddOptions{})
+	if err != nil {
+		t.Errorf("AddVocabulary after the lock was released: %v", err)
+	}
+}
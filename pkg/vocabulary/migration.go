@@ -0,0 +1,247 @@
+package vocabulary
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// schemaTableName is the table recording the schema version of every vocabulary table in the store.
+var schemaTableName = "voca__schema"
+
+// schemaColumns are the columns of the schema table.
+var schemaColumns = []string{"id", "table_name", "version", "created_at", "updated_at"}
+
+// vocabularyColumns are the columns of a vocabulary table once every migration is applied.
+var vocabularyColumns = []string{
+	"id", "word", "read_count", "created_at", "updated_at",
+	"ease", "interval", "repetitions", "lapses", "due_at",
+	"context", "source", "note",
+	"tags", "deck",
+	"status",
+}
+
+// migration upgrades the vocabulary table to a version.
+// Migrations are applied in order and must be safe to re-run,
+// since a failure between apply and recording the version re-runs it on the next open.
+type migration struct {
+	version int
+	name    string
+	apply   func(m *migrator) error
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create vocabulary table",
+		apply: func(m *migrator) error {
+			err := m.createTable(m.tableName, []string{"id", "word", "read_count", "created_at", "updated_at"})
+			if err != nil {
+				return err
+			}
+			return m.backfill(m.tableName, "read_count", func(record csvstore.CSVRecord) string {
+				if record["read_count"] == "" {
+					return "0"
+				}
+				return record["read_count"]
+			})
+		},
+	},
+	{
+		version: 2,
+		name:    "add scheduling columns",
+		apply: func(m *migrator) error {
+			for _, column := range []string{"ease", "interval", "repetitions", "lapses", "due_at"} {
+				err := m.addColumn(m.tableName, column, "")
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 3,
+		name:    "add context, source and note columns",
+		apply: func(m *migrator) error {
+			for _, column := range []string{"context", "source", "note"} {
+				err := m.addColumn(m.tableName, column, "")
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 4,
+		name:    "add tags and deck columns",
+		apply: func(m *migrator) error {
+			for _, column := range []string{"tags", "deck"} {
+				err := m.addColumn(m.tableName, column, "")
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version: 5,
+		name:    "add status column",
+		apply: func(m *migrator) error {
+			return m.addColumn(m.tableName, "status", string(StatusActive))
+		},
+	},
+	{
+		version: 6,
+		name:    "create review log table",
+		apply: func(m *migrator) error {
+			return m.createTable(reviewsTableName(m.tableName), reviewColumns)
+		},
+	},
+	{
+		version: 7,
+		name:    "create explanation cache table",
+		apply: func(m *migrator) error {
+			return m.createTable(explanationsTableName(m.tableName), explanationColumns)
+		},
+	},
+}
+
+// migrator applies migrations to the vocabulary table of a store.
+type migrator struct {
+	backend   Backend
+	tableName string
+}
+
+// migrate applies every migration newer than the recorded schema version of the table.
+// It returns the version the table was migrated to, or 0 when it was already up to date.
+func migrate(backend Backend, tableName string) (int, error) {
+	m := &migrator{backend: backend, tableName: tableName}
+
+	if !backend.CheckTableExists(schemaTableName) {
+		err := backend.CreateTable(schemaTableName, schemaColumns)
+		if err != nil {
+			return 0, fmt.Errorf("error creating schema table: %w", err)
+		}
+	}
+
+	currentVersion, err := m.version()
+	if err != nil {
+		return 0, err
+	}
+	migrated := 0
+	for _, mg := range migrations {
+		if mg.version <= currentVersion {
+			continue
+		}
+		err := mg.apply(m)
+		if err != nil {
+			return 0, fmt.Errorf("error applying migration %d (%s): %w", mg.version, mg.name, err)
+		}
+		err = m.setVersion(mg.version)
+		if err != nil {
+			return 0, fmt.Errorf("error recording migration %d (%s): %w", mg.version, mg.name, err)
+		}
+		migrated = mg.version
+	}
+	return migrated, nil
+}
+
+// version returns the recorded schema version of the table, or 0 when none is recorded.
+func (m *migrator) version() (int, error) {
+	qResult, err := m.backend.Query(schemaTableName, []csvstore.QueryCondition{{
+		Column:   "table_name",
+		Operator: "=",
+		Value:    m.tableName,
+	}})
+	if err != nil {
+		return 0, fmt.Errorf("error getting schema version: %w", err)
+	}
+	if qResult.Count == 0 {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(qResult.Records[0]["version"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version of %s: %w", m.tableName, err)
+	}
+	return version, nil
+}
+
+func (m *migrator) setVersion(version int) error {
+	uResult, err := m.backend.Update(schemaTableName, csvstore.CSVRecord{
+		"version": strconv.Itoa(version),
+	}, []csvstore.QueryCondition{{
+		Column:   "table_name",
+		Operator: "=",
+		Value:    m.tableName,
+	}})
+	if err != nil {
+		return err
+	}
+	if uResult.Count > 0 {
+		return nil
+	}
+	_, err = m.backend.Insert(schemaTableName, csvstore.CSVRecord{
+		"table_name": m.tableName,
+		"version":    strconv.Itoa(version),
+	})
+	return err
+}
+
+// createTable creates the table unless it already exists.
+func (m *migrator) createTable(tableName string, columns []string) error {
+	if m.backend.CheckTableExists(tableName) {
+		return nil
+	}
+	return m.backend.CreateTable(tableName, columns)
+}
+
+// addColumn appends a column filled with defaultValue for every existing row.
+// It does nothing when the column already exists.
+func (m *migrator) addColumn(tableName, column, defaultValue string) error {
+	columns, err := m.backend.Columns(tableName)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(columns, column) {
+		return nil
+	}
+	return m.backend.AddColumn(tableName, column, defaultValue)
+}
+
+// renameColumn renames a column, keeping its position and values.
+// It does nothing when the column was already renamed.
+func (m *migrator) renameColumn(tableName, oldColumn, newColumn string) error {
+	columns, err := m.backend.Columns(tableName)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(columns, newColumn) {
+		return nil
+	}
+	if !slices.Contains(columns, oldColumn) {
+		return fmt.Errorf("column %s not found in %s", oldColumn, tableName)
+	}
+	return m.backend.RenameColumn(tableName, oldColumn, newColumn)
+}
+
+// backfill sets the column of every row to the value computed from the row.
+// It writes the table directly, so the updated_at of the rows is kept.
+func (m *migrator) backfill(
+	tableName string,
+	column string,
+	value func(record csvstore.CSVRecord) string,
+) error {
+	columns, err := m.backend.Columns(tableName)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(columns, column) {
+		return fmt.Errorf("column %s not found in %s", column, tableName)
+	}
+	return m.backend.FillColumn(tableName, column, value)
+}
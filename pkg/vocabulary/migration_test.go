@@ -0,0 +1,289 @@
+package vocabulary
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// copyFixture copies the CSV files of a testdata directory into a new store directory.
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join("testdata", "migrations", name)
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			t.Fatalf("reading fixture %s: %v", name, err)
+		}
+		err = os.WriteFile(filepath.Join(dir, entry.Name()), data, 0o644)
+		if err != nil {
+			t.Fatalf("copying fixture %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// readDir returns the content of every file in the directory, by name.
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
+
+func TestMigrateFixtures(t *testing.T) {
+	const tableName = "eng__voca"
+	latest := migrations[len(migrations)-1].version
+
+	for _, fixture := range []string{"v0", "v1", "v4"} {
+		t.Run(fixture, func(t *testing.T) {
+			dir := copyFixture(t, fixture)
+			backend, err := openBackend(BackendCSV, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			version, err := migrate(backend, tableName)
+			if err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+			if version != latest {
+				t.Errorf("migrate returned version %d, want %d", version, latest)
+			}
+
+			columns, err := backend.Columns(tableName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(columns, vocabularyColumns) {
+				t.Errorf("columns = %v, want %v", columns, vocabularyColumns)
+			}
+			for _, table := range []string{reviewsTableName(tableName), explanationsTableName(tableName)} {
+				if !backend.CheckTableExists(table) {
+					t.Errorf("table %s was not created", table)
+				}
+			}
+
+			recorded, err := (&migrator{backend: backend, tableName: tableName}).version()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recorded != latest {
+				t.Errorf("recorded version = %d, want %d", recorded, latest)
+			}
+
+			qResult, err := backend.Query(tableName, []csvstore.QueryCondition{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if qResult.Count != 2 {
+				t.Fatalf("got %d rows, want 2", qResult.Count)
+			}
+			want := map[string]struct{ readCount, updatedAt string }{
+				"serendipity": {"3", "2024-06-12T09:30:00Z"},
+				"sea change":  {"0", "2024-06-11T08:00:00Z"},
+			}
+			for _, record := range qResult.Records {
+				w := want[record["word"]]
+				if record["read_count"] != w.readCount {
+					t.Errorf("%s: read_count = %q, want %q", record["word"], record["read_count"], w.readCount)
+				}
+				if record["updated_at"] != w.updatedAt {
+					t.Errorf("%s: updated_at = %q, want %q", record["word"], record["updated_at"], w.updatedAt)
+				}
+				if record["status"] != string(StatusActive) {
+					t.Errorf("%s: status = %q, want %q", record["word"], record["status"], StatusActive)
+				}
+			}
+
+			before := readDir(t, dir)
+			version, err = migrate(backend, tableName)
+			if err != nil {
+				t.Fatalf("second migrate: %v", err)
+			}
+			if version != 0 {
+				t.Errorf("second migrate returned version %d, want 0", version)
+			}
+			after := readDir(t, dir)
+			for name, content := range after {
+				if before[name] != content {
+					t.Errorf("second migrate changed %s", name)
+				}
+			}
+			if len(after) != len(before) {
+				t.Errorf("second migrate created files: %d before, %d after", len(before), len(after))
+			}
+		})
+	}
+}
+
+func TestMigratorRenameColumn(t *testing.T) {
+	dir := copyFixture(t, "v1")
+	backend, err := openBackend(BackendCSV, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &migrator{backend: backend, tableName: "eng__voca"}
+
+	err = m.renameColumn(m.tableName, "read_count", "reads")
+	if err != nil {
+		t.Fatalf("renameColumn: %v", err)
+	}
+	columns, err := backend.Columns(m.tableName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns[2] != "reads" || slices.Contains(columns, "read_count") {
+		t.Errorf("columns = %v, want read_count renamed to reads in place", columns)
+	}
+	qResult, err := backend.Query(m.tableName, []csvstore.QueryCondition{{Column: "word", Operator: "=", Value: "serendipity"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qResult.Count != 1 || qResult.Records[0]["reads"] != "3" {
+		t.Errorf("renamed column lost its values: %v", qResult.Records)
+	}
+
+	err = m.renameColumn(m.tableName, "read_count", "reads")
+	if err != nil {
+		t.Errorf("renaming again: %v, want no-op", err)
+	}
+	err = m.renameColumn(m.tableName, "missing", "other")
+	if err == nil {
+		t.Error("renaming a missing column succeeded")
+	}
+}
+
+func TestMigratorBackfillKeepsUpdatedAt(t *testing.T) {
+	for _, kind := range []BackendKind{BackendCSV, BackendSQLite, BackendJSON} {
+		t.Run(string(kind), func(t *testing.T) {
+			backend, err := openBackend(kind, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := &migrator{backend: backend, tableName: "words"}
+			err = m.createTable(m.tableName, []string{"id", "word", "read_count", "created_at", "updated_at"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			const updatedAt = "2024-06-12T09:30:00Z"
+			_, err = backend.Insert(m.tableName, csvstore.CSVRecord{"word": "serendipity", "updated_at": updatedAt})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = m.backfill(m.tableName, "read_count", func(record csvstore.CSVRecord) string {
+				return strconv.Itoa(len(record["word"]))
+			})
+			if err != nil {
+				t.Fatalf("backfill: %v", err)
+			}
+			qResult, err := backend.Query(m.tableName, []csvstore.QueryCondition{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := qResult.Records[0]
+			if record["read_count"] != "11" {
+				t.Errorf("read_count = %q, want 11", record["read_count"])
+			}
+			if record["updated_at"] != updatedAt {
+				t.Errorf("updated_at = %q, want %q", record["updated_at"], updatedAt)
+			}
+		})
+	}
+}
+
+// runGit runs git in dir and returns its trimmed output, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newRemote creates a bare repository with an initial commit on main, to clone git stores from.
+func newRemote(t *testing.T) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "voca")
+	t.Setenv("GIT_AUTHOR_EMAIL", "voca@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "voca")
+	t.Setenv("GIT_COMMITTER_EMAIL", "voca@example.com")
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	runGit(t, dir, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+	seed := filepath.Join(dir, "seed")
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main", seed)
+	err := os.WriteFile(filepath.Join(seed, "README.md"), []byte("voca store\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, seed, "add", "-A")
+	runGit(t, seed, "commit", "--quiet", "-m", "init")
+	runGit(t, seed, "push", "--quiet", remote, "main")
+	return remote
+}
+
+// newGitStore opens a git store cloned from the remote into a new directory.
+func newGitStore(t *testing.T, remote string) *store {
+	t.Helper()
+	s := NewStore(StoreOptions{RemoteURL: remote, Branch: "main", LocalPath: filepath.Join(t.TempDir(), "store")})
+	_, err := s.getBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMigrateCommitsInGitMode(t *testing.T) {
+	remote := newRemote(t)
+	s := newGitStore(t, remote)
+
+	if status := runGit(t, s.storePath, "status", "--porcelain"); status != "" {
+		t.Errorf("migrating left the clone dirty:\n%s", status)
+	}
+	latest := migrations[len(migrations)-1].version
+	want := "chore: migrate eng__voca to v" + strconv.Itoa(latest)
+	if subject := runGit(t, s.storePath, "log", "-1", "--format=%s"); subject != want {
+		t.Errorf("last commit = %q, want %q", subject, want)
+	}
+	if pushed := runGit(t, remote, "log", "-1", "--format=%s", "main"); pushed != want {
+		t.Errorf("remote head = %q, want the migration to be pushed", pushed)
+	}
+
+	// an up-to-date store is not committed again
+	head := runGit(t, s.storePath, "rev-parse", "HEAD")
+	again := NewStore(s.opts)
+	_, err := again.getBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, s.storePath, "rev-parse", "HEAD"); got != head {
+		t.Error("opening an up-to-date store made a commit")
+	}
+}
@@ -0,0 +1,138 @@
+package vocabulary
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// pendingPushFile marks a store whose last push failed. It lives in the git directory
+// so that it is never committed.
+const pendingPushFile = "voca-pending-push"
+
+func (s *store) pendingPushPath() (string, error) {
+	path, err := s.git("rev-parse", "--git-path", pendingPushFile)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.storePath, path)
+	}
+	return path, nil
+}
+
+// markPendingPush records that the local commits could not be pushed, and why.
+func (s *store) markPendingPush(reason error) error {
+	path, err := s.pendingPushPath()
+	if err != nil {
+		return err
+	}
+	content := fmt.Sprintf("%s\n%v\n", time.Now().Format(time.RFC3339), reason)
+	return os.WriteFile(path, []byte(content), 0o644)
+}
+
+func (s *store) clearPendingPush() error {
+	path, err := s.pendingPushPath()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *store) hasPendingPush() bool {
+	path, err := s.pendingPushPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// countUnpushed returns the number of local commits the remote branch did not have when it was last fetched.
+func (s *store) countUnpushed() (int, error) {
+	branch, err := s.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return 0, err
+	}
+	revRange := "HEAD"
+	if _, err := s.git("rev-parse", "--verify", "--quiet", remoteName+"/"+branch); err == nil {
+		revRange = remoteName + "/" + branch + "..HEAD"
+	}
+	output, err := s.git("rev-list", "--count", revRange)
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(output)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected output from git rev-list: %s", output)
+	}
+	return count, nil
+}
+
+// logPendingStatus prints how many local commits wait for `voca sync`, if any.
+func (s *store) logPendingStatus() {
+	count, err := s.countUnpushed()
+	if err != nil || count == 0 {
+		return
+	}
+	log.Printf("%d local commit(s) not pushed yet, run `voca sync` when online\n", count)
+}
+
+// Sync commits any change left in the store, merges the changes of the remote and pushes every local commit.
+// It returns the number of local commits that were pushed.
+func (s *store) Sync() (int, error) {
+	if s.opts.StorageMode != StorageModeGit {
+		return 0, fmt.Errorf("sync requires the git storage mode")
+	}
+	_, err := s.getBackend()
+	if err != nil {
+		return 0, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if !s.checkIsGitRepo() {
+		return 0, fmt.Errorf("store is not a git repository")
+	}
+	status, err := s.git("status", "--porcelain")
+	if err != nil {
+		return 0, err
+	}
+	if status != "" {
+		err = s.commitChanges("save", "save uncommitted changes")
+		if err != nil {
+			return 0, fmt.Errorf("error committing changes: %w", err)
+		}
+	}
+
+	pending, err := s.countUnpushed()
+	if err != nil {
+		return 0, err
+	}
+	err = s.pullAndPush()
+	if err != nil {
+		markErr := s.markPendingPush(err)
+		if markErr != nil {
+			log.Printf("error marking pending push: %v\n", markErr)
+		}
+		return 0, fmt.Errorf("error syncing with the remote: %w", err)
+	}
+	err = s.clearPendingPush()
+	if err != nil {
+		return 0, fmt.Errorf("error clearing pending push: %w", err)
+	}
+	return pending, nil
+}
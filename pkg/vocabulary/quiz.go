@@ -0,0 +1,68 @@
+package vocabulary
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// quizBlank replaces the word in the context of a quiz question.
+const quizBlank = "_____"
+
+// QuizQuestion asks for a word from the sentence it was seen in, with the word blanked out.
+type QuizQuestion struct {
+	WordID string
+	Word   string
+	Cloze  string
+}
+
+// Check reports whether the answer is the word, ignoring case and surrounding spaces.
+func (q QuizQuestion) Check(answer string) bool {
+	return normalizeWord(answer) == normalizeWord(q.Word)
+}
+
+// QuizQuestions picks up to limit active words matching the filter whose context contains them.
+// It only reads the store; the answers are recorded with RecordQuizAnswers.
+func (s *store) QuizQuestions(limit int, filter Filter) ([]QuizQuestion, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	// mastered and archived words are not quizzed
+	filter.Status = StatusActive
+
+	records, err := s.queryVocabulary(backend, filter)
+	if err != nil {
+		return nil, err
+	}
+	questions := make([]QuizQuestion, 0, len(records))
+	for _, record := range records {
+		cloze, ok := clozeContext(record["context"], record["word"])
+		if !ok {
+			continue
+		}
+		questions = append(questions, QuizQuestion{WordID: record["id"], Word: record["word"], Cloze: cloze})
+	}
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+	if limit < len(questions) {
+		questions = questions[:limit]
+	}
+	return questions, nil
+}
+
+// clozeContext blanks out every occurrence of the word in the context, ignoring case.
+// It returns false when the context does not contain the word as a whole word.
+func clozeContext(context string, word string) (string, bool) {
+	if strings.TrimSpace(context) == "" || strings.TrimSpace(word) == "" {
+		return "", false
+	}
+	pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(strings.TrimSpace(word)) + `\b`)
+	if !pattern.MatchString(context) {
+		return "", false
+	}
+	return pattern.ReplaceAllLiteralString(context, quizBlank), true
+}
@@ -0,0 +1,82 @@
+package vocabulary
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClozeContext(t *testing.T) {
+	tests := []struct {
+		context string
+		word    string
+		want    string
+		ok      bool
+	}{
+		{"It was pure serendipity.", "serendipity", "It was pure _____.", true},
+		{"Serendipity, again and again serendipity", "serendipity", "_____, again and again _____", true},
+		{"A sea change in policy", "sea change", "A _____ in policy", true},
+		{"The mitigating factors", "mitigate", "", false},
+		{"", "serendipity", "", false},
+		{"Costs rose (again).", "(again)", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := clozeContext(tt.context, tt.word)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("clozeContext(%q, %q) = %q, %v, want %q, %v", tt.context, tt.word, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRecordQuizAnswers(t *testing.T) {
+	s := newLocalStore(t.TempDir())
+	backend, err := s.getBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.AddVocabulary("serendipity", AddOptions{Context: "It was pure serendipity."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.AddVocabulary("ephemeral", AddOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	questions, err := s.QuizQuestions(10, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) != 1 || questions[0].Word != "serendipity" {
+		t.Fatalf("questions = %+v, want only the word with a context", questions)
+	}
+	question := questions[0]
+	if !question.Check(" Serendipity\n") || question.Check("ephemeral") {
+		t.Error("Check does not compare the normalized answer with the word")
+	}
+
+	answeredAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	err = s.RecordQuizAnswers([]QuizAnswer{
+		{WordID: question.WordID, Correct: true, AnsweredAt: answeredAt},
+		{WordID: question.WordID, Correct: false, AnsweredAt: answeredAt.Add(time.Minute)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reviews, err := s.getReviews(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 2 {
+		t.Fatalf("got %d reviews, want 2", len(reviews))
+	}
+	for i, want := range []string{reviewOutcomeCorrect, reviewOutcomeIncorrect} {
+		if reviews[i]["mode"] != string(ReviewModeQuiz) || reviews[i]["outcome"] != want {
+			t.Errorf("review %d = %s/%s, want %s/%s", i, reviews[i]["mode"], reviews[i]["outcome"], ReviewModeQuiz, want)
+		}
+		if reviews[i]["word_id"] != question.WordID {
+			t.Errorf("review %d is of word %q, want %q", i, reviews[i]["word_id"], question.WordID)
+		}
+	}
+}
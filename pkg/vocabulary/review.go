@@ -0,0 +1,153 @@
+package vocabulary
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// ReviewMode is how a word was reviewed.
+type ReviewMode string
+
+const (
+	// ReviewModeStudy is a word explained by `voca study` and graded afterwards.
+	ReviewModeStudy ReviewMode = "study"
+	// ReviewModeStory is a word that appeared in a story.
+	ReviewModeStory ReviewMode = "story"
+	// ReviewModeQuiz is a quiz answer, with an outcome of correct or incorrect.
+	ReviewModeQuiz ReviewMode = "quiz"
+)
+
+const (
+	reviewOutcomeSeen      = "seen"
+	reviewOutcomeCorrect   = "correct"
+	reviewOutcomeIncorrect = "incorrect"
+)
+
+// reviewColumns are the columns of the review log table.
+var reviewColumns = []string{"id", "word_id", "reviewed_at", "mode", "outcome", "created_at", "updated_at"}
+
+// reviewsTableName returns the name of the review log table of a vocabulary table,
+// such as eng__voca_reviews for eng__voca.
+func reviewsTableName(tableName string) string {
+	return tableName + "_reviews"
+}
+
+// gradeOutcome is the outcome recorded for a graded study.
+func gradeOutcome(grade Grade) string {
+	return strconv.Itoa(int(grade))
+}
+
+// quizOutcome is the outcome recorded for a quiz answer.
+func quizOutcome(correct bool) string {
+	if correct {
+		return reviewOutcomeCorrect
+	}
+	return reviewOutcomeIncorrect
+}
+
+// recordReview appends a review of the word to the review log.
+func (s *store) recordReview(
+	backend Backend,
+	wordID string,
+	mode ReviewMode,
+	outcome string,
+	reviewedAt time.Time,
+) error {
+	_, err := backend.Insert(reviewsTableName(s.opts.TableName), csvstore.CSVRecord{
+		"word_id":     wordID,
+		"reviewed_at": reviewedAt.Format(time.RFC3339Nano),
+		"mode":        string(mode),
+		"outcome":     outcome,
+	})
+	if err != nil {
+		return fmt.Errorf("error recording review: %w", err)
+	}
+	return nil
+}
+
+// QuizAnswer is the answer given to a quiz question about a word.
+type QuizAnswer struct {
+	WordID     string
+	Correct    bool
+	AnsweredAt time.Time
+}
+
+// RecordQuizAnswers records the answers of a quiz in the review log and syncs the store.
+// The answers are recorded once the quiz is over, so that the store is not locked while answering.
+func (s *store) RecordQuizAnswers(answers []QuizAnswer) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	correct := 0
+	for _, answer := range answers {
+		err := s.recordReview(backend, answer.WordID, ReviewModeQuiz, quizOutcome(answer.Correct), answer.AnsweredAt)
+		if err != nil {
+			return err
+		}
+		if answer.Correct {
+			correct++
+		}
+	}
+
+	defer func() {
+		err := s.syncStore("quiz", fmt.Sprintf("answer %d quiz question(s), %d correct", len(answers), correct))
+		if err != nil {
+			log.Printf("error syncing store: %v\n", err)
+		}
+	}()
+
+	return nil
+}
+
+// getReviews returns the review log, oldest first.
+func (s *store) getReviews(backend Backend) ([]csvstore.CSVRecord, error) {
+	qResult, err := backend.Query(reviewsTableName(s.opts.TableName), []csvstore.QueryCondition{})
+	if err != nil {
+		return nil, fmt.Errorf("error querying reviews: %w", err)
+	}
+	slices.SortStableFunc(qResult.Records, func(a, b csvstore.CSVRecord) int {
+		return compareTimestamps(a["reviewed_at"], b["reviewed_at"])
+	})
+	return qResult.Records, nil
+}
+
+// moveReviews reassigns the reviews of one word to another, used when words are merged.
+func (s *store) moveReviews(backend Backend, fromID string, toID string) error {
+	_, err := backend.Update(reviewsTableName(s.opts.TableName), csvstore.CSVRecord{
+		"word_id": toID,
+	}, []csvstore.QueryCondition{{
+		Column:   "word_id",
+		Operator: "=",
+		Value:    fromID,
+	}})
+	if err != nil {
+		return fmt.Errorf("error moving reviews: %w", err)
+	}
+	return nil
+}
+
+// deleteReviews removes the reviews of a deleted word.
+func (s *store) deleteReviews(backend Backend, wordID string) error {
+	_, err := backend.Delete(reviewsTableName(s.opts.TableName), []csvstore.QueryCondition{{
+		Column:   "word_id",
+		Operator: "=",
+		Value:    wordID,
+	}})
+	if err != nil {
+		return fmt.Errorf("error deleting reviews: %w", err)
+	}
+	return nil
+}
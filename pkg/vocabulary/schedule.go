@@ -0,0 +1,113 @@
+package vocabulary
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+const (
+	defaultEase = 2.5
+	minimumEase = 1.3
+)
+
+// Grade is the self-assessed quality of a recall, following the SM-2 scale:
+// 0 is a complete blackout and 5 is a perfect, effortless answer.
+// Grades below 3 count as a lapse and restart the schedule.
+type Grade int
+
+const (
+	GradeBlackout Grade = iota
+	GradeWrong
+	GradeWrongButFamiliar
+	GradeHard
+	GradeGood
+	GradePerfect
+)
+
+// ParseGrade converts user input into a Grade, rejecting anything outside 0-5.
+func ParseGrade(input string) (Grade, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		return 0, fmt.Errorf("grade must be a number between %d and %d", GradeBlackout, GradePerfect)
+	}
+	grade := Grade(n)
+	if grade < GradeBlackout || grade > GradePerfect {
+		return 0, fmt.Errorf("grade must be between %d and %d, got %d", GradeBlackout, GradePerfect, n)
+	}
+	return grade, nil
+}
+
+// schedule is the SM-2 state kept for every vocabulary row.
+type schedule struct {
+	ease        float64
+	interval    int // in days
+	repetitions int
+	lapses      int
+	dueAt       time.Time
+}
+
+// parseSchedule reads the scheduling columns of a record.
+// Rows that were never reviewed have empty columns, so they start with the default ease
+// and are due from the moment they were created.
+func parseSchedule(record csvstore.CSVRecord) schedule {
+	sc := schedule{ease: defaultEase}
+	if ease, err := strconv.ParseFloat(record["ease"], 64); err == nil && ease >= minimumEase {
+		sc.ease = ease
+	}
+	if interval, err := strconv.Atoi(record["interval"]); err == nil {
+		sc.interval = interval
+	}
+	if repetitions, err := strconv.Atoi(record["repetitions"]); err == nil {
+		sc.repetitions = repetitions
+	}
+	if lapses, err := strconv.Atoi(record["lapses"]); err == nil {
+		sc.lapses = lapses
+	}
+	if dueAt, err := time.Parse(time.RFC3339Nano, record["due_at"]); err == nil {
+		sc.dueAt = dueAt
+	} else if createdAt, err := time.Parse(time.RFC3339Nano, record["created_at"]); err == nil {
+		sc.dueAt = createdAt
+	}
+	return sc
+}
+
+// next returns the schedule after a review graded at the given time.
+func (sc schedule) next(grade Grade, now time.Time) schedule {
+	n := sc
+	if grade < GradeHard {
+		n.repetitions = 0
+		n.interval = 1
+		n.lapses++
+	} else {
+		switch n.repetitions {
+		case 0:
+			n.interval = 1
+		case 1:
+			n.interval = 6
+		default:
+			n.interval = int(math.Round(float64(n.interval) * n.ease))
+		}
+		n.repetitions++
+	}
+
+	q := float64(GradePerfect - grade)
+	n.ease = math.Max(minimumEase, n.ease+(0.1-q*(0.08+q*0.02)))
+	n.dueAt = now.AddDate(0, 0, n.interval)
+	return n
+}
+
+// toRecord converts the schedule into the columns to update.
+func (sc schedule) toRecord() csvstore.CSVRecord {
+	return csvstore.CSVRecord{
+		"ease":        strconv.FormatFloat(sc.ease, 'f', 2, 64),
+		"interval":    strconv.Itoa(sc.interval),
+		"repetitions": strconv.Itoa(sc.repetitions),
+		"lapses":      strconv.Itoa(sc.lapses),
+		"due_at":      sc.dueAt.Format(time.RFC3339Nano),
+	}
+}
@@ -0,0 +1,244 @@
+package vocabulary
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+func newLocalStore(dir string) *store {
+	return NewStore(StoreOptions{LocalPath: dir, StorageMode: StorageModeLocal})
+}
+
+func TestScheduleNext(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		from  schedule
+		grade Grade
+		want  schedule
+	}{
+		{
+			name:  "first review",
+			from:  schedule{ease: defaultEase},
+			grade: GradeGood,
+			want:  schedule{ease: 2.5, interval: 1, repetitions: 1},
+		},
+		{
+			name:  "second review",
+			from:  schedule{ease: 2.5, interval: 1, repetitions: 1},
+			grade: GradePerfect,
+			want:  schedule{ease: 2.6, interval: 6, repetitions: 2},
+		},
+		{
+			name:  "later reviews multiply by the ease before it changes",
+			from:  schedule{ease: 2.5, interval: 6, repetitions: 2},
+			grade: GradeHard,
+			want:  schedule{ease: 2.36, interval: 15, repetitions: 3},
+		},
+		{
+			name:  "interval is rounded",
+			from:  schedule{ease: 1.5, interval: 5, repetitions: 4, lapses: 2},
+			grade: GradeGood,
+			want:  schedule{ease: 1.5, interval: 8, repetitions: 5, lapses: 2},
+		},
+		{
+			name:  "low grade restarts the schedule",
+			from:  schedule{ease: 2.5, interval: 15, repetitions: 3, lapses: 1},
+			grade: GradeWrongButFamiliar,
+			want:  schedule{ease: 2.18, interval: 1, repetitions: 0, lapses: 2},
+		},
+		{
+			name:  "blackout",
+			from:  schedule{ease: 2.5, interval: 40, repetitions: 6},
+			grade: GradeBlackout,
+			want:  schedule{ease: 1.7, interval: 1, repetitions: 0, lapses: 1},
+		},
+		{
+			name:  "ease never drops below the minimum",
+			from:  schedule{ease: 1.4, interval: 1, repetitions: 0},
+			grade: GradeWrong,
+			want:  schedule{ease: minimumEase, interval: 1, repetitions: 0, lapses: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.from.next(tt.grade, now)
+			if math.Abs(got.ease-tt.want.ease) > 1e-9 {
+				t.Errorf("ease = %v, want %v", got.ease, tt.want.ease)
+			}
+			if got.interval != tt.want.interval {
+				t.Errorf("interval = %d, want %d", got.interval, tt.want.interval)
+			}
+			if got.repetitions != tt.want.repetitions {
+				t.Errorf("repetitions = %d, want %d", got.repetitions, tt.want.repetitions)
+			}
+			if got.lapses != tt.want.lapses {
+				t.Errorf("lapses = %d, want %d", got.lapses, tt.want.lapses)
+			}
+			if want := now.AddDate(0, 0, tt.want.interval); !got.dueAt.Equal(want) {
+				t.Errorf("dueAt = %s, want %s", got.dueAt, want)
+			}
+		})
+	}
+}
+
+func TestParseGrade(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Grade
+		wantErr bool
+	}{
+		{"0", GradeBlackout, false},
+		{"3", GradeHard, false},
+		{" 5\n", GradePerfect, false},
+		{"6", 0, true},
+		{"-1", 0, true},
+		{"good", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseGrade(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseGrade(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseGrade(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	createdAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	dueAt := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		record csvstore.CSVRecord
+		want   schedule
+	}{
+		{
+			name:   "never reviewed",
+			record: csvstore.CSVRecord{"created_at": createdAt.Format(time.RFC3339Nano)},
+			want:   schedule{ease: defaultEase, dueAt: createdAt},
+		},
+		{
+			name: "reviewed",
+			record: csvstore.CSVRecord{
+				"ease":        "1.85",
+				"interval":    "6",
+				"repetitions": "2",
+				"lapses":      "1",
+				"due_at":      dueAt.Format(time.RFC3339Nano),
+				"created_at":  createdAt.Format(time.RFC3339Nano),
+			},
+			want: schedule{ease: 1.85, interval: 6, repetitions: 2, lapses: 1, dueAt: dueAt},
+		},
+		{
+			name: "malformed values fall back to the defaults",
+			record: csvstore.CSVRecord{
+				"ease":        "easy",
+				"interval":    "1.5",
+				"repetitions": "two",
+				"lapses":      "",
+				"due_at":      "tomorrow",
+				"created_at":  createdAt.Format(time.RFC3339Nano),
+			},
+			want: schedule{ease: defaultEase, dueAt: createdAt},
+		},
+		{
+			name:   "ease below the minimum",
+			record: csvstore.CSVRecord{"ease": "0.5"},
+			want:   schedule{ease: defaultEase},
+		},
+		{
+			name:   "no timestamps",
+			record: csvstore.CSVRecord{},
+			want:   schedule{ease: defaultEase},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSchedule(tt.record)
+			if got.ease != tt.want.ease || got.interval != tt.want.interval ||
+				got.repetitions != tt.want.repetitions || got.lapses != tt.want.lapses {
+				t.Errorf("parseSchedule = %+v, want %+v", got, tt.want)
+			}
+			if !got.dueAt.Equal(tt.want.dueAt) {
+				t.Errorf("dueAt = %s, want %s", got.dueAt, tt.want.dueAt)
+			}
+		})
+	}
+}
+
+func TestGetDueVocabulary(t *testing.T) {
+	s := newLocalStore(t.TempDir())
+	backend, err := s.getBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	fixtures := []struct {
+		word   string
+		dueAt  time.Time
+		status Status
+	}{
+		{"later", now.AddDate(0, 0, 3), StatusActive},
+		{"overdue", now.AddDate(0, 0, -2), StatusActive},
+		{"mastered", now.AddDate(0, 0, -10), StatusMastered},
+		{"due", now.AddDate(0, 0, -1), StatusActive},
+	}
+	for _, w := range fixtures {
+		record, err := s.AddVocabulary(w.word, AddOptions{Force: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = backend.Update(s.opts.TableName, csvstore.CSVRecord{
+			"due_at": w.dueAt.Format(time.RFC3339Nano),
+			"status": string(w.status),
+		}, []csvstore.QueryCondition{{Column: "id", Operator: "=", Value: record["id"]}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 5 {
+		record, err := s.GetDueVocabulary(Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record["word"] != "overdue" {
+			t.Fatalf("got %q, want the most overdue active word", record["word"])
+		}
+	}
+
+	record, err := s.ReviewVocabulary(mustFind(t, s, "overdue"), GradePerfect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due := parseSchedule(record).dueAt; !due.After(now) {
+		t.Errorf("reviewed word is due %s, want after %s", due, now)
+	}
+	record, err = s.GetDueVocabulary(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record["word"] != "due" {
+		t.Errorf("got %q after reviewing the most overdue word, want %q", record["word"], "due")
+	}
+}
+
+func mustFind(t *testing.T, s *store, word string) string {
+	t.Helper()
+	record, err := s.FindVocabulary(word)
+	if err != nil || record == nil {
+		t.Fatalf("finding %q: %v", word, err)
+	}
+	return record["id"]
+}
@@ -0,0 +1,385 @@
+package vocabulary
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jiyeol-lee/csvstore"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteFileName is the database of the SQLite backend in the store directory.
+var sqliteFileName = "voca.db"
+
+// sqliteBackend keeps every table in a single SQLite database, with every column stored as text.
+// Equality conditions run in SQL; the others are evaluated in Go so that they compare values
+// exactly like csvstore does.
+type sqliteBackend struct {
+	db *sql.DB
+}
+
+func newSQLiteBackend(dir string) (*sqliteBackend, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, sqliteFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// a single connection keeps writes serialised within the process
+	db.SetMaxOpenConns(1)
+	return &sqliteBackend{db: db}, nil
+}
+
+// quoteIdentifier quotes a table or column name for SQL.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a string literal for SQL statements that take no parameters.
+func quoteLiteral(value string) string {
+	return `'` + strings.ReplaceAll(value, `'`, `''`) + `'`
+}
+
+func (b *sqliteBackend) CheckTableExists(tableName string) bool {
+	var count int
+	err := b.db.QueryRow(
+		"SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		tableName,
+	).Scan(&count)
+	return err == nil && count > 0
+}
+
+func (b *sqliteBackend) CreateTable(tableName string, columns []string) error {
+	if b.CheckTableExists(tableName) {
+		return fmt.Errorf("table %s already exists", tableName)
+	}
+	definitions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		definitions = append(definitions, quoteIdentifier(column)+" TEXT NOT NULL DEFAULT ''")
+	}
+	_, err := b.db.Exec(fmt.Sprintf(
+		"CREATE TABLE %s (%s)",
+		quoteIdentifier(tableName),
+		strings.Join(definitions, ", "),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", tableName, err)
+	}
+	return nil
+}
+
+func (b *sqliteBackend) Columns(tableName string) ([]string, error) {
+	rows, err := b.db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info(%s) ORDER BY cid", quoteLiteral(tableName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	columns := make([]string, 0)
+	for rows.Next() {
+		var column string
+		err := rows.Scan(&column)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get columns of %s: %w", tableName, err)
+		}
+		columns = append(columns, column)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s: %w", tableName, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", tableName)
+	}
+	return columns, nil
+}
+
+func (b *sqliteBackend) AddColumn(tableName, column, defaultValue string) error {
+	// the default fills the column of existing rows
+	_, err := b.db.Exec(fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN %s TEXT NOT NULL DEFAULT %s",
+		quoteIdentifier(tableName),
+		quoteIdentifier(column),
+		quoteLiteral(defaultValue),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s to %s: %w", column, tableName, err)
+	}
+	return nil
+}
+
+func (b *sqliteBackend) RenameColumn(tableName, oldColumn, newColumn string) error {
+	_, err := b.db.Exec(fmt.Sprintf(
+		"ALTER TABLE %s RENAME COLUMN %s TO %s",
+		quoteIdentifier(tableName),
+		quoteIdentifier(oldColumn),
+		quoteIdentifier(newColumn),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to rename column %s of %s: %w", oldColumn, tableName, err)
+	}
+	return nil
+}
+
+func (b *sqliteBackend) FillColumn(tableName, column string, value func(record csvstore.CSVRecord) string) error {
+	columns, err := b.Columns(tableName)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(columns, column) {
+		return fmt.Errorf("column %s not found in %s", column, tableName)
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := b.selectRows(tx, tableName, columns, []csvstore.QueryCondition{})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		newValue := value(maps.Clone(row.record))
+		if newValue == row.record[column] {
+			continue
+		}
+		_, err := tx.Exec(fmt.Sprintf(
+			"UPDATE %s SET %s = ? WHERE rowid = ?",
+			quoteIdentifier(tableName),
+			quoteIdentifier(column),
+		), newValue, row.rowID)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", tableName, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// sqliteRow is a row along with its rowid, used to update or delete exactly the matched rows.
+type sqliteRow struct {
+	rowID  int64
+	record csvstore.CSVRecord
+}
+
+// selectRows returns the rows matching the conditions in insertion order.
+func (b *sqliteBackend) selectRows(
+	q interface {
+		Query(query string, args ...any) (*sql.Rows, error)
+	},
+	tableName string,
+	columns []string,
+	conditions []csvstore.QueryCondition,
+) ([]sqliteRow, error) {
+	where := make([]string, 0)
+	args := make([]any, 0)
+	for _, condition := range conditions {
+		// csvstore never matches a condition on an unknown column
+		if !slices.Contains(columns, condition.Column) {
+			return []sqliteRow{}, nil
+		}
+		if condition.Operator == "=" || condition.Operator == "==" {
+			where = append(where, quoteIdentifier(condition.Column)+" = ?")
+			args = append(args, condition.Value)
+		}
+	}
+
+	selected := make([]string, 0, len(columns)+1)
+	selected = append(selected, "rowid")
+	for _, column := range columns {
+		selected = append(selected, quoteIdentifier(column))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selected, ", "), quoteIdentifier(tableName))
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY rowid"
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	result := make([]sqliteRow, 0)
+	for rows.Next() {
+		var rowID int64
+		values := make([]string, len(columns))
+		dest := make([]any, 0, len(columns)+1)
+		dest = append(dest, &rowID)
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		err := rows.Scan(dest...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", tableName, err)
+		}
+		record := make(csvstore.CSVRecord, len(columns))
+		for i, column := range columns {
+			record[column] = values[i]
+		}
+		if matchesConditions(record, conditions) {
+			result = append(result, sqliteRow{rowID: rowID, record: record})
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", tableName, err)
+	}
+	return result, nil
+}
+
+func (b *sqliteBackend) Insert(tableName string, record csvstore.CSVRecord) (csvstore.CSVRecord, error) {
+	columns, err := b.Columns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	row := newRow(columns, record)
+
+	quoted := make([]string, 0, len(columns))
+	placeholders := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, quoteIdentifier(column))
+		placeholders = append(placeholders, "?")
+		args = append(args, row[column])
+	}
+	_, err = b.db.Exec(fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdentifier(tableName),
+		strings.Join(quoted, ", "),
+		strings.Join(placeholders, ", "),
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert into %s: %w", tableName, err)
+	}
+	return row, nil
+}
+
+func (b *sqliteBackend) Query(
+	tableName string,
+	conditions []csvstore.QueryCondition,
+) (*csvstore.QueryResult, error) {
+	columns, err := b.Columns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := b.selectRows(b.db, tableName, columns, conditions)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]csvstore.CSVRecord, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, row.record)
+	}
+	return &csvstore.QueryResult{Records: records, Count: len(records)}, nil
+}
+
+func (b *sqliteBackend) QuerySortedRange(
+	tableName string,
+	sortField string,
+	sortBy string,
+	limit int,
+) (*csvstore.QueryResult, error) {
+	qResult, err := b.Query(tableName, []csvstore.QueryCondition{})
+	if err != nil {
+		return nil, err
+	}
+	return sortedRange(tableName, qResult.Records, sortField, sortBy, limit)
+}
+
+func (b *sqliteBackend) Update(
+	tableName string,
+	updates csvstore.CSVRecord,
+	conditions []csvstore.QueryCondition,
+) (*csvstore.QueryResult, error) {
+	columns, err := b.Columns(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := b.selectRows(tx, tableName, columns, conditions)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]csvstore.CSVRecord, 0, len(rows))
+	for _, row := range rows {
+		updated := updateRow(columns, row.record, updates)
+		assignments := make([]string, 0, len(columns))
+		args := make([]any, 0, len(columns)+1)
+		for _, column := range columns {
+			assignments = append(assignments, quoteIdentifier(column)+" = ?")
+			args = append(args, updated[column])
+		}
+		args = append(args, row.rowID)
+		_, err := tx.Exec(fmt.Sprintf(
+			"UPDATE %s SET %s WHERE rowid = ?",
+			quoteIdentifier(tableName),
+			strings.Join(assignments, ", "),
+		), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", tableName, err)
+		}
+		records = append(records, maps.Clone(updated))
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &csvstore.QueryResult{Records: records, Count: len(records)}, nil
+}
+
+func (b *sqliteBackend) Delete(
+	tableName string,
+	conditions []csvstore.QueryCondition,
+) (*csvstore.QueryResult, error) {
+	columns, err := b.Columns(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := b.selectRows(tx, tableName, columns, conditions)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]csvstore.CSVRecord, 0, len(rows))
+	for _, row := range rows {
+		_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE rowid = ?", quoteIdentifier(tableName)), row.rowID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", tableName, err)
+		}
+		records = append(records, row.record)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &csvstore.QueryResult{Records: records, Count: len(records)}, nil
+}
@@ -0,0 +1,340 @@
+package vocabulary
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+const (
+	statsWeeks    = 12
+	statsDays     = 30
+	heatmapWeeks  = 53
+	statsTopWords = 5
+)
+
+// sparkTicks are the bars of a sparkline, from the lowest to the highest value.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// heatLevels are the cells of the heatmap, from no activity to the most active day.
+var heatLevels = []rune("·░▒▓█")
+
+// reviewOperations are the voca commits that reviewed words.
+// Commits made before operations were recorded are "sync", and most of them were reads.
+var reviewOperations = []string{"review", "story", "quiz", "sync"}
+
+// PrintStats prints the growth of the vocabulary and the review activity:
+// totals, words added per week, reviews per day, streaks, the most and never reviewed words,
+// and a heatmap of the last year.
+func (s *store) PrintStats() error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	records, err := s.queryVocabulary(backend, Filter{})
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no vocabulary found")
+	}
+	activity, err := s.reviewActivity(backend, records)
+	if err != nil {
+		return err
+	}
+
+	today := calendarDay(time.Now())
+	statusCounts := map[Status]int{}
+	addedPerWeek := make([]int, statsWeeks)
+	for _, record := range records {
+		statusCounts[Status(record["status"])]++
+		createdAt, err := time.Parse(time.RFC3339Nano, record["created_at"])
+		if err != nil {
+			continue
+		}
+		week := daysBetween(calendarDay(createdAt), today) / 7
+		if week >= 0 && week < statsWeeks {
+			addedPerWeek[statsWeeks-1-week]++
+		}
+	}
+	reviewsPerDay := make([]int, statsDays)
+	for i := range reviewsPerDay {
+		reviewsPerDay[i] = activity[today.AddDate(0, 0, i-statsDays+1)]
+	}
+	current, longest := streaks(activity, today)
+
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	fmt.Fprintf(
+		writer,
+		"Words\t%d (%d active, %d mastered, %d archived)\n",
+		len(records),
+		statusCounts[StatusActive],
+		statusCounts[StatusMastered],
+		statusCounts[StatusArchived],
+	)
+	fmt.Fprintf(writer, "Added\t%s\t%d in the last %d weeks\n", sparkline(addedPerWeek), sum(addedPerWeek), statsWeeks)
+	fmt.Fprintf(writer, "Reviews\t%s\t%d in the last %d days\n", sparkline(reviewsPerDay), sum(reviewsPerDay), statsDays)
+	fmt.Fprintf(writer, "Streak\t%s (longest %s)\n", pluralDays(current), pluralDays(longest))
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Print(heatmap(activity, today))
+
+	fmt.Println()
+	err = printMostReviewed(records)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printNeverReviewed(records)
+
+	return nil
+}
+
+// reviewActivity returns the number of reviews per calendar day.
+// Reviews come from the review log. For the time before the first logged review,
+// voca commits that reviewed words stand in for them in git storage mode,
+// and the updated_at of words that were read does in local storage mode.
+func (s *store) reviewActivity(
+	backend Backend,
+	records []csvstore.CSVRecord,
+) (map[time.Time]int, error) {
+	reviews, err := s.getReviews(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := map[time.Time]int{}
+	var loggedSince time.Time
+	for _, review := range reviews {
+		reviewedAt, err := time.Parse(time.RFC3339Nano, review["reviewed_at"])
+		if err != nil {
+			continue
+		}
+		if loggedSince.IsZero() || reviewedAt.Before(loggedSince) {
+			loggedSince = reviewedAt
+		}
+		activity[calendarDay(reviewedAt)]++
+	}
+	beforeLog := func(t time.Time) bool {
+		return loggedSince.IsZero() || t.Before(loggedSince)
+	}
+
+	if s.opts.StorageMode == StorageModeGit {
+		history, err := s.getHistory(0)
+		if err != nil {
+			return nil, fmt.Errorf("error getting history: %w", err)
+		}
+		for _, e := range history {
+			if slices.Contains(reviewOperations, e.operation) && beforeLog(e.date) {
+				activity[calendarDay(e.date)]++
+			}
+		}
+		return activity, nil
+	}
+
+	for _, record := range records {
+		if atoiOrZero(record["read_count"]) == 0 {
+			continue
+		}
+		updatedAt, err := time.Parse(time.RFC3339Nano, record["updated_at"])
+		if err == nil && beforeLog(updatedAt) {
+			activity[calendarDay(updatedAt)]++
+		}
+	}
+	return activity, nil
+}
+
+// printMostReviewed prints the words with the highest read_count.
+func printMostReviewed(records []csvstore.CSVRecord) error {
+	reviewed := slices.DeleteFunc(slices.Clone(records), func(record csvstore.CSVRecord) bool {
+		return atoiOrZero(record["read_count"]) == 0
+	})
+	if len(reviewed) == 0 {
+		fmt.Println("No word has been reviewed yet")
+		return nil
+	}
+	slices.SortStableFunc(reviewed, func(a, b csvstore.CSVRecord) int {
+		return atoiOrZero(b["read_count"]) - atoiOrZero(a["read_count"])
+	})
+
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	_, err := writer.Write([]byte("Most reviewed\tRead\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, record := range reviewed[:min(statsTopWords, len(reviewed))] {
+		_, err := fmt.Fprintf(writer, "%s\t%s\n", record["word"], record["read_count"])
+		if err != nil {
+			return fmt.Errorf("failed to write stats data: %w", err)
+		}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
+
+// printNeverReviewed prints the number of active words that were never reviewed and the oldest of them.
+func printNeverReviewed(records []csvstore.CSVRecord) {
+	never := slices.DeleteFunc(slices.Clone(records), func(record csvstore.CSVRecord) bool {
+		return atoiOrZero(record["read_count"]) > 0 || Status(record["status"]) != StatusActive
+	})
+	if len(never) == 0 {
+		fmt.Println("Every active word has been reviewed")
+		return
+	}
+	slices.SortStableFunc(never, func(a, b csvstore.CSVRecord) int {
+		return compareTimestamps(a["created_at"], b["created_at"])
+	})
+
+	words := make([]string, 0, statsTopWords)
+	for _, record := range never[:min(statsTopWords, len(never))] {
+		words = append(words, record["word"])
+	}
+	if len(never) > len(words) {
+		words = append(words, "...")
+	}
+	fmt.Printf("Never reviewed: %d (%s)\n", len(never), strings.Join(words, ", "))
+}
+
+// heatmap renders the activity of the last year as a GitHub-style grid,
+// one column per week and one row per weekday.
+func heatmap(activity map[time.Time]int, today time.Time) string {
+	start := today.AddDate(0, 0, -int(today.Weekday())-(heatmapWeeks-1)*7)
+	maxCount := 0
+	for day, count := range activity {
+		if !day.Before(start) && !day.After(today) {
+			maxCount = max(maxCount, count)
+		}
+	}
+
+	var b strings.Builder
+
+	// month labels are placed above the first week of every month when there is room
+	months := []rune(strings.Repeat(" ", heatmapWeeks))
+	free := 0
+	for week := range heatmapWeeks {
+		weekStart := start.AddDate(0, 0, week*7)
+		if week > 0 && weekStart.Month() == weekStart.AddDate(0, 0, -7).Month() {
+			continue
+		}
+		label := []rune(weekStart.Format("Jan"))
+		if week < free || week+len(label) > heatmapWeeks {
+			continue
+		}
+		copy(months[week:], label)
+		free = week + len(label) + 1
+	}
+	fmt.Fprintf(&b, "    %s\n", strings.TrimRight(string(months), " "))
+
+	weekdayLabels := []string{"", "Mon", "", "Wed", "", "Fri", ""}
+	for weekday := range 7 {
+		fmt.Fprintf(&b, "%-4s", weekdayLabels[weekday])
+		for week := range heatmapWeeks {
+			day := start.AddDate(0, 0, week*7+weekday)
+			if day.After(today) {
+				break
+			}
+			b.WriteRune(heatLevels[scale(activity[day], maxCount, len(heatLevels)-1)])
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "    Less %s More\n", strings.Join(strings.Split(string(heatLevels), ""), " "))
+	return b.String()
+}
+
+// sparkline renders the values as bars relative to the largest value.
+func sparkline(values []int) string {
+	maxValue := slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		b.WriteRune(sparkTicks[scale(v, maxValue, len(sparkTicks)-1)])
+	}
+	return b.String()
+}
+
+// scale maps a value between 0 and maxValue onto a level between 0 and levels,
+// where only 0 maps to level 0.
+func scale(value, maxValue, levels int) int {
+	if value <= 0 || maxValue <= 0 {
+		return 0
+	}
+	return min(levels, (value*levels+maxValue-1)/maxValue)
+}
+
+// streaks returns the current and longest number of consecutive days with activity.
+// The current streak is kept alive until the end of today.
+func streaks(activity map[time.Time]int, today time.Time) (current int, longest int) {
+	days := make([]time.Time, 0, len(activity))
+	for day, count := range activity {
+		if count > 0 {
+			days = append(days, day)
+		}
+	}
+	slices.SortFunc(days, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+
+	run := 0
+	for i, day := range days {
+		if i > 0 && daysBetween(days[i-1], day) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	day := today
+	if activity[day] == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+	for activity[day] > 0 {
+		current++
+		day = day.AddDate(0, 0, -1)
+	}
+	return current, longest
+}
+
+// calendarDay returns the local calendar day of t as midnight UTC,
+// so days can be used as map keys and counted without daylight saving shifts.
+func calendarDay(t time.Time) time.Time {
+	year, month, day := t.Local().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of days from one calendar day to another.
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
@@ -0,0 +1,76 @@
+package vocabulary
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// Status is the learning state of a word.
+// Only active words come up in study and story.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusMastered Status = "mastered"
+	StatusArchived Status = "archived"
+)
+
+var statuses = []Status{StatusActive, StatusMastered, StatusArchived}
+
+// ParseStatus validates the name of a status.
+func ParseStatus(name string) (Status, error) {
+	status := Status(name)
+	if !slices.Contains(statuses, status) {
+		return "", fmt.Errorf("unsupported status: %s (expected active, mastered or archived)", name)
+	}
+	return status, nil
+}
+
+func (s *store) SetStatus(word string, status Status) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	record, err := s.FindVocabulary(word)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("vocabulary not found: %s", word)
+	}
+	if Status(record["status"]) == status {
+		return nil, fmt.Errorf("vocabulary is already %s: %s", status, word)
+	}
+
+	uResult, err := backend.Update(s.opts.TableName, csvstore.CSVRecord{
+		"status": string(status),
+	}, []csvstore.QueryCondition{
+		{
+			Column:   "id",
+			Operator: "=",
+			Value:    record["id"],
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating status: %w", err)
+	}
+
+	defer func() {
+		err := s.syncStore("status", fmt.Sprintf("mark %q as %s", record["word"], status))
+		if err != nil {
+			log.Printf("error syncing store: %v\n", err)
+		}
+	}()
+
+	return uResult.Records[0], nil
+}
@@ -0,0 +1,373 @@
+package vocabulary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// remoteName is the git remote the store is cloned from.
+const remoteName = "origin"
+
+// pullOperation is the operation of the commits merging remote changes.
+const pullOperation = "pull"
+
+// pushAttempts is how many times a rejected push is retried after merging the remote again.
+var pushAttempts = 3
+
+// pullAndPush brings the changes of the remote into the local branch and pushes the result.
+// When both sides have new commits, the remote is merged with mergeRemote. Nothing is ever force-pushed.
+func (s *store) pullAndPush() error {
+	branch, err := s.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return err
+	}
+	remoteBranch := remoteName + "/" + branch
+
+	var pushErr error
+	for range pushAttempts {
+		_, err := s.git("fetch", remoteName)
+		if err != nil {
+			return err
+		}
+		_, err = s.git("rev-parse", "--verify", "--quiet", remoteBranch)
+		if err != nil {
+			// the remote is empty or does not have the branch yet
+			_, err = s.git("push", "--set-upstream", remoteName, branch)
+			return err
+		}
+
+		behind, ahead, err := s.countDivergence(remoteBranch)
+		if err != nil {
+			return err
+		}
+		if ahead == 0 {
+			if behind > 0 {
+				_, err = s.git("merge", "--ff-only", remoteBranch)
+			}
+			return err
+		}
+		if behind > 0 {
+			err = s.mergeRemote(remoteBranch)
+			if err != nil {
+				return err
+			}
+		}
+
+		// the push is rejected when the remote moved since the fetch, so fetch and merge again
+		_, pushErr = s.git("push", remoteName, "HEAD:"+branch)
+		if pushErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", pushAttempts, pushErr)
+}
+
+// countDivergence returns the number of commits only on the remote branch and only on HEAD.
+func (s *store) countDivergence(remoteBranch string) (behind int, ahead int, err error) {
+	output, err := s.git("rev-list", "--left-right", "--count", remoteBranch+"...HEAD")
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := strings.Fields(output)
+	if len(counts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list: %s", output)
+	}
+	behind, err = strconv.Atoi(counts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list: %s", output)
+	}
+	ahead, err = strconv.Atoi(counts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected output from git rev-list: %s", output)
+	}
+	return behind, ahead, nil
+}
+
+// mergeRemote merges the remote branch into HEAD and commits the merge.
+// Files changed on one side take that change, and CSV tables and the tables of the JSON backend
+// changed on both sides are merged row by row with mergeCSVTables.
+// Any other file changed on both sides, such as a SQLite database, aborts the merge.
+func (s *store) mergeRemote(remoteBranch string) error {
+	base, err := s.git("merge-base", "HEAD", remoteBranch)
+	if err != nil {
+		return err
+	}
+	baseFiles, err := s.treeFiles(base)
+	if err != nil {
+		return err
+	}
+	ourFiles, err := s.treeFiles("HEAD")
+	if err != nil {
+		return err
+	}
+	theirFiles, err := s.treeFiles(remoteBranch)
+	if err != nil {
+		return err
+	}
+
+	// start a merge that keeps our tree, then bring in the remote changes file by file
+	_, err = s.git("merge", "--no-commit", "--no-ff", "--strategy=ours", remoteBranch)
+	if err != nil {
+		return err
+	}
+	abort := func(err error) error {
+		_, abortErr := s.git("merge", "--abort")
+		if abortErr != nil {
+			return fmt.Errorf("%w (and aborting the merge failed: %v)", err, abortErr)
+		}
+		return err
+	}
+
+	paths := slices.Sorted(maps.Keys(ourFiles))
+	for path := range theirFiles {
+		if _, ok := ourFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range paths {
+		baseBlob, ourBlob, theirBlob := baseFiles[path], ourFiles[path], theirFiles[path]
+		switch {
+		case ourBlob == theirBlob, theirBlob == baseBlob:
+			continue
+		case ourBlob == baseBlob && theirBlob == "":
+			_, err = s.git("rm", "--quiet", "--", path)
+		case ourBlob == baseBlob:
+			_, err = s.git("checkout", remoteBranch, "--", path)
+		case filepath.Ext(path) == ".csv" && !strings.Contains(path, "/"):
+			err = s.mergeTableFile(path, baseBlob, ourBlob, theirBlob)
+		case path == jsonFileName:
+			err = s.mergeJSONFile(path, baseBlob, ourBlob, theirBlob)
+		default:
+			err = fmt.Errorf("cannot merge %s: it was changed both locally and on the remote", path)
+		}
+		if err != nil {
+			return abort(err)
+		}
+	}
+
+	err = s.commitChanges(pullOperation, fmt.Sprintf("merge changes from %s", remoteBranch))
+	if err != nil {
+		return abort(err)
+	}
+	return nil
+}
+
+// treeFiles returns the blob of every file in the tree of a revision, by path.
+func (s *store) treeFiles(rev string) (map[string]string, error) {
+	output, err := s.git("ls-tree", "-r", "-z", rev)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for entry := range strings.SplitSeq(output, "\x00") {
+		// <mode> SP <type> SP <object> TAB <path>
+		info, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		files[path] = fields[2]
+	}
+	return files, nil
+}
+
+// readBlobTable reads a version of a table from git. An empty blob is a table that does not exist.
+func (s *store) readBlobTable(path string, blob string) (*csvTable, error) {
+	if blob == "" {
+		return &csvTable{path: path}, nil
+	}
+	content, err := s.git("cat-file", "blob", blob)
+	if err != nil {
+		return nil, err
+	}
+	return parseCSVTable(path, bytes.NewBufferString(content))
+}
+
+// mergeTableFile writes the row-level merge of the versions of a table to the working tree.
+func (s *store) mergeTableFile(path, baseBlob, ourBlob, theirBlob string) error {
+	base, err := s.readBlobTable(path, baseBlob)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	ours, err := s.readBlobTable(path, ourBlob)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	theirs, err := s.readBlobTable(path, theirBlob)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	merged := mergeCSVTables(base, ours, theirs, tableKey(strings.TrimSuffix(path, ".csv")))
+	merged.path = filepath.Join(s.storePath, path)
+	return merged.write()
+}
+
+// readBlobJSONTables reads a version of the tables of the JSON backend from git.
+// An empty blob is a store without tables.
+func (s *store) readBlobJSONTables(blob string) (map[string]*jsonTable, error) {
+	tables := map[string]*jsonTable{}
+	if blob == "" {
+		return tables, nil
+	}
+	content, err := s.git("cat-file", "blob", blob)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal([]byte(content), &tables)
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// mergeJSONFile writes the merge of the versions of the JSON backend file to the working tree,
+// merging every table row by row like the CSV tables.
+func (s *store) mergeJSONFile(path, baseBlob, ourBlob, theirBlob string) error {
+	versions := make([]map[string]*jsonTable, 0, 3)
+	for _, blob := range []string{baseBlob, ourBlob, theirBlob} {
+		tables, err := s.readBlobJSONTables(blob)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", path, err)
+		}
+		versions = append(versions, tables)
+	}
+	base, ours, theirs := versions[0], versions[1], versions[2]
+
+	tableNames := slices.Collect(maps.Keys(ours))
+	for tableName := range theirs {
+		if _, ok := ours[tableName]; !ok {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	merged := make(map[string]*jsonTable, len(tableNames))
+	for _, tableName := range tableNames {
+		table := mergeCSVTables(
+			base[tableName].csvTable(),
+			ours[tableName].csvTable(),
+			theirs[tableName].csvTable(),
+			tableKey(tableName),
+		)
+		rows := make([]csvstore.CSVRecord, 0, len(table.rows))
+		for _, row := range table.rows {
+			rows = append(rows, table.record(row))
+		}
+		merged[tableName] = &jsonTable{Columns: table.headers, Rows: rows}
+	}
+
+	backend := &jsonBackend{path: filepath.Join(s.storePath, path)}
+	return backend.save(merged)
+}
+
+// tableKey returns the column identifying the rows of a table across versions.
+// The schema table has a single row per vocabulary table, whichever side created it.
+func tableKey(tableName string) string {
+	if tableName == schemaTableName {
+		return "table_name"
+	}
+	return "id"
+}
+
+// mergeCSVTables merges two versions of a table that diverged from base, matching rows by the key column.
+// Rows added on either side are kept, rows deleted on one side are deleted unless the other side
+// changed them, and rows changed on both sides are merged with mergeRow.
+// Rows keep our order, followed by the rows only the remote has.
+func mergeCSVTables(base, ours, theirs *csvTable, key string) *csvTable {
+	headers := slices.Clone(ours.headers)
+	for _, header := range theirs.headers {
+		if !slices.Contains(headers, header) {
+			headers = append(headers, header)
+		}
+	}
+
+	baseRows := base.recordsBy(key)
+	theirRows := theirs.recordsBy(key)
+	merged := &csvTable{headers: headers}
+	appendRecord := func(record csvstore.CSVRecord) {
+		row := make([]string, len(headers))
+		for i, header := range headers {
+			row[i] = record[header]
+		}
+		merged.rows = append(merged.rows, row)
+	}
+
+	seen := make(map[string]bool)
+	for _, row := range ours.rows {
+		ourRecord := ours.record(row)
+		id := ourRecord[key]
+		seen[id] = true
+		baseRecord, inBase := baseRows[id]
+		theirRecord, inTheirs := theirRows[id]
+		switch {
+		case inTheirs:
+			appendRecord(mergeRow(headers, baseRecord, ourRecord, theirRecord))
+		case !inBase || !maps.Equal(baseRecord, ourRecord):
+			// added by us, or deleted by them after we changed it
+			appendRecord(ourRecord)
+		}
+	}
+	for _, row := range theirs.rows {
+		theirRecord := theirs.record(row)
+		id := theirRecord[key]
+		if seen[id] {
+			continue
+		}
+		baseRecord, inBase := baseRows[id]
+		if !inBase || !maps.Equal(baseRecord, theirRecord) {
+			appendRecord(theirRecord)
+		}
+	}
+	return merged
+}
+
+// mergeRow merges two versions of a row. A column changed on one side only takes that change.
+// A column changed on both sides takes the larger read_count or schema version, the tags added or kept by both,
+// the latest updated_at, and otherwise the value of the side that was updated last.
+func mergeRow(headers []string, base, ours, theirs csvstore.CSVRecord) csvstore.CSVRecord {
+	oursIsLatest := compareTimestamps(ours["updated_at"], theirs["updated_at"]) >= 0
+	merged := make(csvstore.CSVRecord, len(headers))
+	for _, header := range headers {
+		baseValue, ourValue, theirValue := base[header], ours[header], theirs[header]
+		switch {
+		case ourValue == theirValue, theirValue == baseValue:
+			merged[header] = ourValue
+		case ourValue == baseValue:
+			merged[header] = theirValue
+		case header == "read_count", header == "version":
+			merged[header] = strconv.Itoa(max(atoiOrZero(ourValue), atoiOrZero(theirValue)))
+		case header == "tags":
+			merged[header] = mergeTags(baseValue, ourValue, theirValue)
+		case header == "updated_at":
+			merged[header] = ourValue
+			if compareTimestamps(theirValue, ourValue) > 0 {
+				merged[header] = theirValue
+			}
+		case oursIsLatest:
+			merged[header] = ourValue
+		default:
+			merged[header] = theirValue
+		}
+	}
+	return merged
+}
+
+// mergeTags keeps the tags added on either side and drops the tags removed on either side.
+func mergeTags(base, ours, theirs string) string {
+	baseTags, ourTags, theirTags := parseTags(base), parseTags(ours), parseTags(theirs)
+	merged := make([]string, 0, len(ourTags)+len(theirTags))
+	for _, tag := range slices.Concat(ourTags, theirTags) {
+		removed := slices.Contains(baseTags, tag) && (!slices.Contains(ourTags, tag) || !slices.Contains(theirTags, tag))
+		if !removed {
+			merged = append(merged, tag)
+		}
+	}
+	return formatTags(merged)
+}
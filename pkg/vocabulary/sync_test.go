@@ -0,0 +1,308 @@
+package vocabulary
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+func TestMergeSchemaTableByTableName(t *testing.T) {
+	headers := []string{"id", "table_name", "version", "created_at", "updated_at"}
+	base := &csvTable{headers: headers}
+	// both sides migrated a new table, creating its schema row with a different id
+	ours := &csvTable{headers: headers, rows: [][]string{
+		{"1", "eng__voca", "7", "2024-06-01T00:00:00Z", "2024-06-01T00:00:00Z"},
+	}}
+	theirs := &csvTable{headers: headers, rows: [][]string{
+		{"2", "eng__voca", "5", "2024-06-02T00:00:00Z", "2024-06-02T00:00:00Z"},
+		{"3", "deu__voca", "7", "2024-06-02T00:00:00Z", "2024-06-02T00:00:00Z"},
+	}}
+
+	merged := mergeCSVTables(base, ours, theirs, tableKey(schemaTableName))
+	if len(merged.rows) != 2 {
+		t.Fatalf("got %d schema rows, want one per table: %v", len(merged.rows), merged.rows)
+	}
+	versions := merged.recordsBy("table_name")
+	if got := versions["eng__voca"]["version"]; got != "7" {
+		t.Errorf("eng__voca version = %s, want the highest, 7", got)
+	}
+	if got := versions["deu__voca"]["version"]; got != "7" {
+		t.Errorf("deu__voca version = %s, want 7", got)
+	}
+}
+
+func TestMergeRow(t *testing.T) {
+	headers := []string{"id", "word", "read_count", "tags", "note", "updated_at"}
+	tests := []struct {
+		name               string
+		base, ours, theirs csvstore.CSVRecord
+		want               csvstore.CSVRecord
+	}{
+		{
+			name:   "changed on one side",
+			base:   csvstore.CSVRecord{"id": "1", "word": "sea chnge", "read_count": "1", "updated_at": "2024-06-01T00:00:00Z"},
+			ours:   csvstore.CSVRecord{"id": "1", "word": "sea chnge", "read_count": "1", "updated_at": "2024-06-01T00:00:00Z"},
+			theirs: csvstore.CSVRecord{"id": "1", "word": "sea change", "read_count": "1", "updated_at": "2024-06-02T00:00:00Z"},
+			want:   csvstore.CSVRecord{"id": "1", "word": "sea change", "read_count": "1", "updated_at": "2024-06-02T00:00:00Z"},
+		},
+		{
+			name:   "read count takes the larger value",
+			base:   csvstore.CSVRecord{"id": "1", "read_count": "1", "updated_at": "2024-06-01T00:00:00Z"},
+			ours:   csvstore.CSVRecord{"id": "1", "read_count": "4", "updated_at": "2024-06-02T00:00:00Z"},
+			theirs: csvstore.CSVRecord{"id": "1", "read_count": "2", "updated_at": "2024-06-03T00:00:00Z"},
+			want:   csvstore.CSVRecord{"id": "1", "read_count": "4", "updated_at": "2024-06-03T00:00:00Z"},
+		},
+		{
+			name:   "updated_at takes the latest time, whatever its zone",
+			base:   csvstore.CSVRecord{"id": "1", "updated_at": "2024-06-01T00:00:00Z"},
+			ours:   csvstore.CSVRecord{"id": "1", "updated_at": "2024-06-02T00:00:00Z"},
+			theirs: csvstore.CSVRecord{"id": "1", "updated_at": "2024-06-01T23:00:00-02:00"},
+			want:   csvstore.CSVRecord{"id": "1", "updated_at": "2024-06-01T23:00:00-02:00"},
+		},
+		{
+			name:   "other columns take the side updated last",
+			base:   csvstore.CSVRecord{"id": "1", "note": "", "updated_at": "2024-06-01T00:00:00Z"},
+			ours:   csvstore.CSVRecord{"id": "1", "note": "ours", "updated_at": "2024-06-03T00:00:00Z"},
+			theirs: csvstore.CSVRecord{"id": "1", "note": "theirs", "updated_at": "2024-06-02T00:00:00Z"},
+			want:   csvstore.CSVRecord{"id": "1", "note": "ours", "updated_at": "2024-06-03T00:00:00Z"},
+		},
+		{
+			name:   "tags are merged",
+			base:   csvstore.CSVRecord{"id": "1", "tags": "work", "updated_at": "2024-06-01T00:00:00Z"},
+			ours:   csvstore.CSVRecord{"id": "1", "tags": "idiom,work", "updated_at": "2024-06-02T00:00:00Z"},
+			theirs: csvstore.CSVRecord{"id": "1", "tags": "podcast", "updated_at": "2024-06-03T00:00:00Z"},
+			want:   csvstore.CSVRecord{"id": "1", "tags": "idiom,podcast", "updated_at": "2024-06-03T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeRow(headers, tt.base, tt.ours, tt.theirs)
+			for _, header := range headers {
+				if got[header] != tt.want[header] {
+					t.Errorf("%s = %q, want %q", header, got[header], tt.want[header])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+	}{
+		{name: "added on both sides", base: "", ours: "work", theirs: "idiom", want: "idiom,work"},
+		{name: "same tag added on both sides", base: "", ours: "work", theirs: "work", want: "work"},
+		{name: "removed on our side", base: "idiom,work", ours: "idiom", theirs: "idiom,work,podcast", want: "idiom,podcast"},
+		{name: "removed on their side", base: "idiom,work", ours: "idiom,work", theirs: "work", want: "work"},
+		{name: "removed on both sides", base: "work", ours: "", theirs: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeTags(tt.base, tt.ours, tt.theirs); got != tt.want {
+				t.Errorf("mergeTags(%q, %q, %q) = %q, want %q", tt.base, tt.ours, tt.theirs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeCSVTables(t *testing.T) {
+	headers := []string{"id", "word", "read_count", "updated_at"}
+	table := func(rows ...[]string) *csvTable {
+		return &csvTable{headers: headers, rows: rows}
+	}
+	tests := []struct {
+		name               string
+		base, ours, theirs *csvTable
+		want               [][]string
+	}{
+		{
+			name:   "added on either side",
+			base:   table(),
+			ours:   table([]string{"1", "serendipity", "0", "2024-06-01T00:00:00Z"}),
+			theirs: table([]string{"2", "ephemeral", "0", "2024-06-02T00:00:00Z"}),
+			want: [][]string{
+				{"1", "serendipity", "0", "2024-06-01T00:00:00Z"},
+				{"2", "ephemeral", "0", "2024-06-02T00:00:00Z"},
+			},
+		},
+		{
+			name:   "same row added on both sides",
+			base:   table(),
+			ours:   table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			theirs: table([]string{"1", "serendipity", "2", "2024-06-02T00:00:00Z"}),
+			want:   [][]string{{"1", "serendipity", "2", "2024-06-02T00:00:00Z"}},
+		},
+		{
+			name:   "deleted on our side, unchanged on theirs",
+			base:   table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			ours:   table(),
+			theirs: table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			want:   nil,
+		},
+		{
+			name:   "deleted on their side, unchanged on ours",
+			base:   table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			ours:   table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			theirs: table(),
+			want:   nil,
+		},
+		{
+			name:   "deleted on our side, modified on theirs",
+			base:   table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			ours:   table(),
+			theirs: table([]string{"1", "serendipity", "2", "2024-06-02T00:00:00Z"}),
+			want:   [][]string{{"1", "serendipity", "2", "2024-06-02T00:00:00Z"}},
+		},
+		{
+			name:   "modified on our side, deleted on theirs",
+			base:   table([]string{"1", "serendipity", "1", "2024-06-01T00:00:00Z"}),
+			ours:   table([]string{"1", "serendipity", "2", "2024-06-02T00:00:00Z"}),
+			theirs: table(),
+			want:   [][]string{{"1", "serendipity", "2", "2024-06-02T00:00:00Z"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeCSVTables(tt.base, tt.ours, tt.theirs, "id")
+			if !slices.Equal(merged.headers, headers) {
+				t.Errorf("headers = %v, want %v", merged.headers, headers)
+			}
+			if !slices.EqualFunc(merged.rows, tt.want, slices.Equal) {
+				t.Errorf("rows = %v, want %v", merged.rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeJSONFile(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet")
+	s := &store{storePath: dir}
+
+	columns := []string{"id", "word", "read_count", "updated_at"}
+	blob := func(tables map[string]*jsonTable) string {
+		t.Helper()
+		data, err := json.Marshal(tables)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "blob.json")
+		err = os.WriteFile(path, data, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runGit(t, dir, "hash-object", "-w", path)
+	}
+	words := func(rows ...csvstore.CSVRecord) map[string]*jsonTable {
+		return map[string]*jsonTable{"eng__voca": {Columns: columns, Rows: rows}}
+	}
+	serendipity := csvstore.CSVRecord{"id": "1", "word": "serendipity", "read_count": "1", "updated_at": "2024-06-01T00:00:00Z"}
+	read := csvstore.CSVRecord{"id": "1", "word": "serendipity", "read_count": "3", "updated_at": "2024-06-03T00:00:00Z"}
+	ephemeral := csvstore.CSVRecord{"id": "2", "word": "ephemeral", "read_count": "0", "updated_at": "2024-06-02T00:00:00Z"}
+
+	base := blob(words(serendipity))
+	ours := blob(words(read))
+	theirTables := words(serendipity, ephemeral)
+	// a table only the remote has is kept as it is
+	theirTables["eng__voca_reviews"] = &jsonTable{Columns: []string{"id", "word_id"}, Rows: []csvstore.CSVRecord{{"id": "9", "word_id": "2"}}}
+	theirs := blob(theirTables)
+
+	err := s.mergeJSONFile(jsonFileName, base, ours, theirs)
+	if err != nil {
+		t.Fatalf("mergeJSONFile: %v", err)
+	}
+
+	merged, err := (&jsonBackend{path: filepath.Join(dir, jsonFileName)}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 {
+		t.Errorf("got %d tables, want 2", len(merged))
+	}
+	rows := merged["eng__voca"].Rows
+	if len(rows) != 2 || !maps.Equal(rows[0], read) || !maps.Equal(rows[1], ephemeral) {
+		t.Errorf("eng__voca rows = %v, want %v and %v", rows, read, ephemeral)
+	}
+	if reviews := merged["eng__voca_reviews"]; reviews == nil || len(reviews.Rows) != 1 || reviews.Rows[0]["word_id"] != "2" {
+		t.Errorf("eng__voca_reviews = %v, want the remote table", reviews)
+	}
+}
+
+func TestPullAndPushDivergedClones(t *testing.T) {
+	remote := newRemote(t)
+	laptop := newGitStore(t, remote)
+	_, err := laptop.AddVocabulary("sea change", AddOptions{Tags: []string{"work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desktop := newGitStore(t, remote)
+
+	// both clones change the store without seeing each other's changes
+	_, err = laptop.AddVocabulary("serendipity", AddOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = laptop.EditTags("sea change", TagEdit{Add: []string{"idiom"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// desktop makes its changes in a single commit, so that its tag edit is merged with the one of laptop
+	err = desktop.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = desktop.AddVocabulary("ephemeral", AddOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = desktop.EditTags("sea change", TagEdit{Add: []string{"podcast"}, Remove: []string{"work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = desktop.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// desktop merged the remote when it pushed, and laptop fast-forwards to that merge
+	err = laptop.pullAndPush()
+	if err != nil {
+		t.Fatalf("pullAndPush: %v", err)
+	}
+	if head, remoteHead := runGit(t, laptop.storePath, "rev-parse", "HEAD"), runGit(t, remote, "rev-parse", "main"); head != remoteHead {
+		t.Errorf("laptop is at %s, want the remote head %s", head, remoteHead)
+	}
+	if parents := strings.Fields(runGit(t, remote, "log", "-1", "--format=%p", "main")); len(parents) != 2 {
+		t.Errorf("remote head has parents %v, want a merge commit", parents)
+	}
+
+	for _, s := range []*store{laptop, desktop} {
+		if status := runGit(t, s.storePath, "status", "--porcelain"); status != "" {
+			t.Errorf("%s is dirty:\n%s", s.storePath, status)
+		}
+	}
+	for _, word := range []string{"serendipity", "ephemeral"} {
+		record, err := laptop.FindVocabulary(word)
+		if err != nil || record == nil {
+			t.Errorf("%q is missing after the merge: %v", word, err)
+		}
+	}
+	record, err := laptop.FindVocabulary("sea change")
+	if err != nil || record == nil {
+		t.Fatalf("finding %q: %v", "sea change", err)
+	}
+	if record["tags"] != "idiom,podcast" {
+		t.Errorf("tags = %q, want the tags added on both sides without the removed one", record["tags"])
+	}
+}
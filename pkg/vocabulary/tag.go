@@ -0,0 +1,159 @@
+package vocabulary
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jiyeol-lee/csvstore"
+)
+
+// TagEdit describes a change to the tags and deck of a word.
+type TagEdit struct {
+	Add    []string
+	Remove []string
+	// Deck replaces the deck of the word when not nil. An empty deck removes the word from its deck.
+	Deck *string
+}
+
+// normalizeTag lowercases a tag and replaces inner spaces with dashes,
+// so that tags are stable regardless of how they were typed.
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// normalizeTags normalizes, deduplicates and sorts tags, dropping empty ones.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t := normalizeTag(tag)
+		if t != "" && !slices.Contains(normalized, t) {
+			normalized = append(normalized, t)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+// parseTags splits the tags column of a record.
+func parseTags(value string) []string {
+	return normalizeTags(strings.Split(value, ","))
+}
+
+// formatTags joins tags for the tags column of a record.
+func formatTags(tags []string) string {
+	return strings.Join(normalizeTags(tags), ",")
+}
+
+func (s *store) EditTags(word string, edit TagEdit) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	record, err := s.FindVocabulary(word)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("vocabulary not found: %s", word)
+	}
+
+	tags := parseTags(record["tags"])
+	tags = append(tags, edit.Add...)
+	removed := normalizeTags(edit.Remove)
+	tags = slices.DeleteFunc(normalizeTags(tags), func(tag string) bool {
+		return slices.Contains(removed, tag)
+	})
+	updates := csvstore.CSVRecord{
+		"tags": formatTags(tags),
+	}
+	if edit.Deck != nil {
+		updates["deck"] = normalizeTag(*edit.Deck)
+	}
+
+	uResult, err := backend.Update(s.opts.TableName, updates, []csvstore.QueryCondition{
+		{
+			Column:   "id",
+			Operator: "=",
+			Value:    record["id"],
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating tags: %w", err)
+	}
+
+	defer func() {
+		err := s.syncStore("tag", fmt.Sprintf("edit tags of %q", record["word"]))
+		if err != nil {
+			log.Printf("error syncing store: %v\n", err)
+		}
+	}()
+
+	return uResult.Records[0], nil
+}
+
+// ListTags lists every deck and tag with the number of words in it.
+func (s *store) ListTags() error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	records, err := s.queryVocabulary(backend, Filter{})
+	if err != nil {
+		return err
+	}
+
+	deckCounts := map[string]int{}
+	tagCounts := map[string]int{}
+	for _, record := range records {
+		if record["deck"] != "" {
+			deckCounts[record["deck"]]++
+		}
+		for _, tag := range parseTags(record["tags"]) {
+			tagCounts[tag]++
+		}
+	}
+	if len(deckCounts) == 0 && len(tagCounts) == 0 {
+		return fmt.Errorf("no tags or decks found")
+	}
+
+	writer := tabwriter.NewWriter(
+		os.Stdout, 0, 2, 4, ' ', 0,
+	)
+	_, err = writer.Write([]byte("Kind\tName\tWords\n"))
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, kind := range []struct {
+		name   string
+		counts map[string]int
+	}{{"deck", deckCounts}, {"tag", tagCounts}} {
+		names := make([]string, 0, len(kind.counts))
+		for name := range kind.counts {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			_, err := fmt.Fprintf(writer, "%s\t%s\t%d\n", kind.name, name, kind.counts[name])
+			if err != nil {
+				return fmt.Errorf("failed to write tag data: %w", err)
+			}
+		}
+	}
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	return nil
+}
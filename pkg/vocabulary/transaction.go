@@ -0,0 +1,134 @@
+package vocabulary
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// transaction is a unit of work on the store: the mutations made while it is open
+// are synced as a single commit when it is committed, and discarded when it is rolled back.
+type transaction struct {
+	operations []string
+	summaries  []string
+	// snapshot holds the store files as they were before the first mutation, by path.
+	// It is nil until a mutation takes the lock, which is then held until the transaction ends.
+	snapshot map[string][]byte
+}
+
+// Begin starts a transaction. Until Commit or Rollback, mutations are not synced on their own.
+// The lock of the store is taken by the first mutation, so a command can read the store
+// and wait for input before changing anything without blocking other voca processes.
+func (s *store) Begin() error {
+	_, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+	if s.tx != nil {
+		return fmt.Errorf("transaction already in progress")
+	}
+	s.tx = &transaction{}
+	return nil
+}
+
+// Commit syncs the mutations made since Begin as one commit, described by their summaries.
+// Nothing is committed when there were no mutations.
+func (s *store) Commit() error {
+	tx := s.tx
+	if tx == nil {
+		return fmt.Errorf("no transaction in progress")
+	}
+	s.tx = nil
+	if tx.snapshot == nil {
+		return nil
+	}
+	defer s.release()
+
+	if len(tx.operations) == 0 {
+		return nil
+	}
+	operations := make([]string, 0, len(tx.operations))
+	for _, operation := range tx.operations {
+		if !slices.Contains(operations, operation) {
+			operations = append(operations, operation)
+		}
+	}
+	return s.syncStore(strings.Join(operations, ", "), strings.Join(tx.summaries, "; "))
+}
+
+// Rollback restores the store files changed since Begin.
+// It does nothing when the transaction was already committed, so it can be deferred.
+func (s *store) Rollback() error {
+	tx := s.tx
+	if tx == nil {
+		return nil
+	}
+	s.tx = nil
+	if tx.snapshot == nil {
+		return nil
+	}
+	defer s.release()
+
+	err := restoreStoreFiles(s.storePath, tx.snapshot)
+	if err != nil {
+		return fmt.Errorf("error rolling back changes: %w", err)
+	}
+	return nil
+}
+
+// record adds a mutation to the transaction in place of syncing it.
+func (tx *transaction) record(operation string, summary string) {
+	tx.operations = append(tx.operations, operation)
+	tx.summaries = append(tx.summaries, summary)
+}
+
+// readStoreFiles reads the files of the store directory, leaving out directories such as .git.
+func readStoreFiles(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading store directory: %w", err)
+	}
+	files := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading store file: %w", err)
+		}
+		files[path] = data
+	}
+	return files, nil
+}
+
+// restoreStoreFiles writes the files of the snapshot back and removes the ones created since.
+func restoreStoreFiles(dir string, snapshot map[string][]byte) error {
+	current, err := readStoreFiles(dir)
+	if err != nil {
+		return err
+	}
+	for path := range current {
+		if _, ok := snapshot[path]; ok {
+			continue
+		}
+		err := os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("error removing store file: %w", err)
+		}
+	}
+	for path, data := range snapshot {
+		if existing, ok := current[path]; ok && bytes.Equal(existing, data) {
+			continue
+		}
+		err := os.WriteFile(path, data, 0o644)
+		if err != nil {
+			return fmt.Errorf("error restoring store file: %w", err)
+		}
+	}
+	return nil
+}
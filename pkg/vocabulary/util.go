@@ -1,6 +1,15 @@
 package vocabulary
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// normalizeWord is the form a word is stored and looked up in.
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
 
 func checkIsFolderExists(path string) bool {
 	info, err := os.Stat(path)
@@ -9,3 +18,29 @@ func checkIsFolderExists(path string) bool {
 	}
 	return info.IsDir()
 }
+
+// getDataDir returns $XDG_DATA_HOME/voca, falling back to ~/.local/share/voca.
+func getDataDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, "voca"), nil
+}
+
+// getCacheDir returns $XDG_CACHE_HOME/voca, falling back to ~/.cache/voca.
+func getCacheDir() (string, error) {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		cacheHome = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheHome, "voca"), nil
+}
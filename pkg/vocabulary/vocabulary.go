@@ -4,39 +4,108 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jiyeol-lee/csvstore"
+	"github.com/jiyeol-lee/voca/pkg/lemma"
 )
 
-var vocabularyTableName = "eng__voca"
+var defaultTableName = "eng__voca"
+
+// StorageMode decides how the store is persisted.
+type StorageMode string
+
+const (
+	// StorageModeGit keeps the store in a clone of a git repository and syncs every change.
+	StorageModeGit StorageMode = "git"
+	// StorageModeLocal keeps the store in a plain directory without git.
+	StorageModeLocal StorageMode = "local"
+)
+
+// StoreOptions configures where the vocabulary store lives.
+type StoreOptions struct {
+	// RemoteURL is the git remote cloned into the local path.
+	RemoteURL string
+	// Branch is the branch to check out. The remote default branch is used when empty.
+	Branch string
+	// LocalPath is the directory of the store.
+	// When empty, a clone kept in the voca cache directory is used in git mode
+	// and the voca data directory in local mode.
+	LocalPath string
+	// TableName is the CSV table holding the vocabulary.
+	TableName string
+	// StorageMode defaults to StorageModeGit when empty.
+	StorageMode StorageMode
+	// Backend is how the tables are kept in the store directory. It defaults to BackendCSV when empty.
+	Backend BackendKind
+}
 
 type store struct {
-	cs        *csvstore.CSVStore
+	backend   Backend
 	storePath string
+	opts      StoreOptions
+	// lockDepth counts the nested holders of the store lock in this process.
+	lockDepth int
+	unlock    func()
+	// tx is the transaction in progress, if any.
+	tx *transaction
 }
 
-func NewStore() *store {
+func NewStore(opts StoreOptions) *store {
+	if opts.TableName == "" {
+		opts.TableName = defaultTableName
+	}
+	if opts.StorageMode == "" {
+		opts.StorageMode = StorageModeGit
+	}
+	if opts.Backend == "" {
+		opts.Backend = BackendCSV
+	}
 	return &store{
-		cs:        nil,
+		backend:   nil,
 		storePath: "",
+		opts:      opts,
 	}
 }
 
-func (s *store) AddVocabulary(word string) (csvstore.CSVRecord, error) {
-	cs, err := s.getCSVStore()
+// AddOptions is the optional information captured along with a new word.
+type AddOptions struct {
+	// Context is the sentence the word appeared in.
+	Context string
+	// Source is where the word was found, such as a URL or a title.
+	Source string
+	// Note is a free-form note.
+	Note string
+	// Tags are the tags of the word.
+	Tags []string
+	// Deck is the deck the word belongs to.
+	Deck string
+	// Force adds the word even when another form of it is already in the store.
+	Force bool
+}
+
+func (s *store) AddVocabulary(word string, opts AddOptions) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
 	if err != nil {
-		return nil, fmt.Errorf("error getting CSV store: %w", err)
+		return nil, fmt.Errorf("error getting store backend: %w", err)
 	}
 
-	lowercaseWord := strings.ToLower(strings.TrimSpace(word))
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-	qResult, err := cs.Query(vocabularyTableName, []csvstore.QueryCondition{{
+	lowercaseWord := normalizeWord(word)
+	if lowercaseWord == "" {
+		return nil, fmt.Errorf("word cannot be empty")
+	}
+
+	qResult, err := backend.Query(s.opts.TableName, []csvstore.QueryCondition{{
 		Column:   "word",
 		Operator: "=",
 		Value:    lowercaseWord,
@@ -47,17 +116,26 @@ func (s *store) AddVocabulary(word string) (csvstore.CSVRecord, error) {
 	if qResult.Count > 0 {
 		return nil, fmt.Errorf("vocabulary already exists: %s", word)
 	}
+	if !opts.Force {
+		related, err := s.findRelatedVocabulary(backend, lowercaseWord)
+		if err != nil {
+			return nil, err
+		}
+		if related != "" {
+			return nil, fmt.Errorf(
+				"vocabulary with the same lemma already exists: %s (add with -force to keep both)",
+				related,
+			)
+		}
+	}
 
-	newVocab, err := cs.Insert(vocabularyTableName, csvstore.CSVRecord{
-		"word":       lowercaseWord,
-		"read_count": "0",
-	})
+	newVocab, err := backend.Insert(s.opts.TableName, newVocabularyRecord(lowercaseWord, opts))
 	if err != nil {
 		return nil, fmt.Errorf("error inserting new vocabulary: %w", err)
 	}
 
 	defer func() {
-		err := s.syncStore()
+		err := s.syncStore("add", fmt.Sprintf("add %q", lowercaseWord))
 		if err != nil {
 			log.Printf("error syncing store: %v\n", err)
 		}
@@ -66,15 +144,106 @@ func (s *store) AddVocabulary(word string) (csvstore.CSVRecord, error) {
 	return newVocab, nil
 }
 
+// VocabularyEntry is a word to add along with its optional information.
+type VocabularyEntry struct {
+	Word string
+	AddOptions
+}
+
+// AddVocabularies adds every entry that is not in the store yet and syncs the store once.
+// Entries that already exist or repeat an earlier entry, including other forms of the same lemma
+// unless the entry is forced, are returned as skipped.
+func (s *store) AddVocabularies(entries []VocabularyEntry) ([]csvstore.CSVRecord, []string, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	existing, err := s.queryVocabulary(backend, Filter{})
+	if err != nil {
+		return nil, nil, err
+	}
+	seen := make(map[string]bool, len(existing)+len(entries))
+	seenLemmas := make(map[string]bool)
+	for _, record := range existing {
+		seen[record["word"]] = true
+		for _, l := range lemma.Lemmas(record["word"]) {
+			seenLemmas[l] = true
+		}
+	}
+
+	added := make([]csvstore.CSVRecord, 0, len(entries))
+	skipped := make([]string, 0)
+	for _, entry := range entries {
+		lowercaseWord := normalizeWord(entry.Word)
+		if lowercaseWord == "" {
+			continue
+		}
+		lemmas := lemma.Lemmas(lowercaseWord)
+		if seen[lowercaseWord] || (!entry.Force && slices.ContainsFunc(lemmas, func(l string) bool {
+			return seenLemmas[l]
+		})) {
+			skipped = append(skipped, entry.Word)
+			continue
+		}
+		seen[lowercaseWord] = true
+		for _, l := range lemmas {
+			seenLemmas[l] = true
+		}
+
+		newVocab, err := backend.Insert(s.opts.TableName, newVocabularyRecord(lowercaseWord, entry.AddOptions))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error inserting new vocabulary: %w", err)
+		}
+		added = append(added, newVocab)
+	}
+
+	if len(added) > 0 {
+		defer func() {
+			err := s.syncStore("add", fmt.Sprintf("add %d words", len(added)))
+			if err != nil {
+				log.Printf("error syncing store: %v\n", err)
+			}
+		}()
+	}
+
+	return added, skipped, nil
+}
+
+func newVocabularyRecord(lowercaseWord string, opts AddOptions) csvstore.CSVRecord {
+	return csvstore.CSVRecord{
+		"word":       lowercaseWord,
+		"read_count": "0",
+		"context":    strings.TrimSpace(opts.Context),
+		"source":     strings.TrimSpace(opts.Source),
+		"note":       strings.TrimSpace(opts.Note),
+		"tags":       formatTags(opts.Tags),
+		"deck":       normalizeTag(opts.Deck),
+		"status":     string(StatusActive),
+	}
+}
+
 func (s *store) DeleteVocabulary(word string) error {
-	cs, err := s.getCSVStore()
+	backend, err := s.getBackend()
 	if err != nil {
-		return fmt.Errorf("error getting CSV store: %w", err)
+		return fmt.Errorf("error getting store backend: %w", err)
 	}
 
-	lowercaseWord := strings.ToLower(strings.TrimSpace(word))
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	qResult, err := cs.Query(vocabularyTableName, []csvstore.QueryCondition{{
+	lowercaseWord := normalizeWord(word)
+
+	qResult, err := backend.Query(s.opts.TableName, []csvstore.QueryCondition{{
 		Column:   "word",
 		Operator: "=",
 		Value:    lowercaseWord,
@@ -86,7 +255,7 @@ func (s *store) DeleteVocabulary(word string) error {
 		return fmt.Errorf("vocabulary not found: %s", word)
 	}
 
-	qResult, err = cs.Delete(vocabularyTableName, []csvstore.QueryCondition{
+	qResult, err = backend.Delete(s.opts.TableName, []csvstore.QueryCondition{
 		{
 			Column:   "word",
 			Operator: "=",
@@ -96,9 +265,19 @@ func (s *store) DeleteVocabulary(word string) error {
 	if err != nil {
 		return fmt.Errorf("error deleting vocabulary: %w", err)
 	}
+	for _, record := range qResult.Records {
+		err = s.deleteReviews(backend, record["id"])
+		if err != nil {
+			return err
+		}
+		err = s.deleteExplanations(backend, record["id"])
+		if err != nil {
+			return err
+		}
+	}
 
 	defer func() {
-		err := s.syncStore()
+		err := s.syncStore("delete", fmt.Sprintf("delete %q", lowercaseWord))
 		if err != nil {
 			log.Printf("error syncing store: %v\n", err)
 		}
@@ -107,182 +286,280 @@ func (s *store) DeleteVocabulary(word string) error {
 	return nil
 }
 
-type selectedWord struct {
-	id        string
-	word      string
-	readCount string
+// FindVocabulary returns the record of the word, or nil when it is not in the store.
+func (s *store) FindVocabulary(word string) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	qResult, err := backend.Query(s.opts.TableName, []csvstore.QueryCondition{{
+		Column:   "word",
+		Operator: "=",
+		Value:    normalizeWord(word),
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("error while checking existing vocabulary: %w", err)
+	}
+	if qResult.Count == 0 {
+		return nil, nil
+	}
+	return qResult.Records[0], nil
 }
 
-func (s *store) GetRandomWords(limit int) ([]string, error) {
-	cs, err := s.getCSVStore()
+// GetRandomWords picks up to limit active words matching the filter for a story.
+// It only reads the store, so that the story can be told without holding the lock;
+// RecordStory records the words once it was.
+func (s *store) GetRandomWords(limit int, filter Filter) ([]csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
 	if err != nil {
-		return nil, fmt.Errorf("error getting CSV store: %w", err)
+		return nil, fmt.Errorf("error getting store backend: %w", err)
 	}
 
-	qResults, err := cs.Query(vocabularyTableName, []csvstore.QueryCondition{
-		{
-			Column:   "word",
-			Operator: "!=",
-			Value:    "",
-		},
-	})
+	// mastered and archived words do not come up in stories
+	filter.Status = StatusActive
+
+	records, err := s.queryVocabulary(backend, filter)
 	if err != nil {
-		return nil, fmt.Errorf("error getting vocabulary: %w", err)
+		return nil, err
 	}
-	resultsLen := qResults.Count
+	resultsLen := len(records)
 	if resultsLen == 0 {
-		return []string{}, nil
+		return []csvstore.CSVRecord{}, nil
 	}
 	if limit > resultsLen {
 		limit = resultsLen
 	}
 	perm := rand.Perm(resultsLen)
-	selectedWords := make([]selectedWord, 0, limit)
+	selected := make([]csvstore.CSVRecord, 0, limit)
 	for _, idx := range perm[:limit] {
-		record := qResults.Records[idx]
-		selectedWords = append(selectedWords, selectedWord{
-			id:        record["id"],
-			word:      record["word"],
-			readCount: record["read_count"],
-		})
+		selected = append(selected, records[idx])
 	}
-	defer func() {
-		for _, w := range selectedWords {
-			oldReadCount, err := strconv.Atoi(w.readCount)
-			if err != nil {
-				log.Printf("error converting read_count to int: %v\n", err)
-				continue
-			}
-			newReadCount := strconv.Itoa(oldReadCount + 1)
-			cs.Update(vocabularyTableName, csvstore.CSVRecord{
-				"read_count": newReadCount,
-			}, []csvstore.QueryCondition{
-				{
-					Column:   "id",
-					Operator: "=",
-					Value:    w.id,
-				},
-			})
+	return selected, nil
+}
+
+// RecordStory counts a read of every word told in a story and logs it as a story review.
+func (s *store) RecordStory(words []csvstore.CSVRecord) error {
+	backend, err := s.getBackend()
+	if err != nil {
+		return fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := time.Now()
+	for _, w := range words {
+		// the read count is read again, since another process may have changed it during the story
+		conditions := []csvstore.QueryCondition{{
+			Column:   "id",
+			Operator: "=",
+			Value:    w["id"],
+		}}
+		qResult, err := backend.Query(s.opts.TableName, conditions)
+		if err != nil {
+			return fmt.Errorf("error while checking existing vocabulary: %w", err)
+		}
+		if qResult.Count == 0 {
+			// deleted in the meantime
+			continue
 		}
-		err = s.syncStore()
+		_, err = backend.Update(s.opts.TableName, csvstore.CSVRecord{
+			"read_count": strconv.Itoa(atoiOrZero(qResult.Records[0]["read_count"]) + 1),
+		}, conditions)
+		if err != nil {
+			return fmt.Errorf("error updating read count: %w", err)
+		}
+		err = s.recordReview(backend, w["id"], ReviewModeStory, reviewOutcomeSeen, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	defer func() {
+		err := s.syncStore("story", fmt.Sprintf("read %d words in a story", len(words)))
 		if err != nil {
 			log.Printf("error syncing store: %v\n", err)
 		}
 	}()
 
-	words := make([]string, 0, limit)
-	for _, w := range selectedWords {
-		words = append(words, w.word)
-	}
-	return words, nil
+	return nil
 }
 
-func (s *store) GetLeastReadVocabulary() (csvstore.CSVRecord, error) {
-	cs, err := s.getCSVStore()
+func (s *store) GetDueVocabulary(filter Filter) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
 	if err != nil {
-		return nil, fmt.Errorf("error getting CSV store: %w", err)
+		return nil, fmt.Errorf("error getting store backend: %w", err)
 	}
 
-	qSortedResults, err := cs.QuerySortedRange(vocabularyTableName, "read_count", "asc", 1)
+	// mastered and archived words are not studied anymore
+	filter.Status = StatusActive
+
+	records, err := s.queryVocabulary(backend, filter)
 	if err != nil {
-		return nil, fmt.Errorf("error getting vocabulary: %w", err)
+		return nil, err
 	}
-	if qSortedResults.Count == 0 {
+	if len(records) == 0 {
 		return nil, fmt.Errorf("no vocabulary found")
 	}
 
-	// why query again?
-	// to pick one vocabulary within the least read count randomly
-	leastReadCount := qSortedResults.Records[0]["read_count"]
-	qResult, err := cs.Query(vocabularyTableName, []csvstore.QueryCondition{
+	// shuffle first so that words due at the same time are picked randomly
+	rand.Shuffle(len(records), func(i, j int) {
+		records[i], records[j] = records[j], records[i]
+	})
+	mostOverdue := records[0]
+	mostOverdueDueAt := parseSchedule(mostOverdue).dueAt
+	for _, record := range records[1:] {
+		dueAt := parseSchedule(record).dueAt
+		if dueAt.Before(mostOverdueDueAt) {
+			mostOverdue = record
+			mostOverdueDueAt = dueAt
+		}
+	}
+
+	return mostOverdue, nil
+}
+
+func (s *store) ReviewVocabulary(id string, grade Grade) (csvstore.CSVRecord, error) {
+	backend, err := s.getBackend()
+	if err != nil {
+		return nil, fmt.Errorf("error getting store backend: %w", err)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	qResult, err := backend.Query(s.opts.TableName, []csvstore.QueryCondition{{
+		Column:   "id",
+		Operator: "=",
+		Value:    id,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("error while checking existing vocabulary: %w", err)
+	}
+	if qResult.Count == 0 {
+		return nil, fmt.Errorf("vocabulary not found: %s", id)
+	}
+
+	record := qResult.Records[0]
+	now := time.Now()
+	updates := parseSchedule(record).next(grade, now).toRecord()
+	readCount, err := strconv.Atoi(record["read_count"])
+	if err != nil {
+		log.Printf("error converting read_count to int: %v\n", err)
+	}
+	updates["read_count"] = strconv.Itoa(readCount + 1)
+
+	uResult, err := backend.Update(s.opts.TableName, updates, []csvstore.QueryCondition{
 		{
-			Column:   "read_count",
+			Column:   "id",
 			Operator: "=",
-			Value:    leastReadCount,
+			Value:    id,
 		},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("error querying vocabulary with least read count: %w", err)
+		return nil, fmt.Errorf("error updating vocabulary schedule: %w", err)
 	}
-	if qResult.Count == 0 {
-		return nil, fmt.Errorf("no vocabulary found with least read count: %s", leastReadCount)
+	err = s.recordReview(backend, id, ReviewModeStudy, gradeOutcome(grade), now)
+	if err != nil {
+		return nil, err
 	}
 
-	// pick one integer from 0 to qResultsLen-1
-	randomInt := rand.Intn(qResult.Count)
-	leastReadVoca := qResult.Records[randomInt]
 	defer func() {
-		currentReadCountString, ok := leastReadVoca["read_count"]
-		if !ok {
-			log.Printf("read_count not found in leastReadVoca: %v\n", leastReadVoca)
-		}
-		currentReadCountInt, err := strconv.Atoi(currentReadCountString)
-		if err != nil {
-			log.Printf("error converting read_count to int: %v\n", err)
-		}
-		leastReadVoca["read_count"] = strconv.Itoa(currentReadCountInt + 1)
-		cs.Update(vocabularyTableName, leastReadVoca, []csvstore.QueryCondition{
-			{
-				Column:   "id",
-				Operator: "=",
-				Value:    leastReadVoca["id"],
-			},
-		})
-
-		err = s.syncStore()
+		err := s.syncStore("review", fmt.Sprintf("review %q with grade %d", record["word"], grade))
 		if err != nil {
 			log.Printf("error syncing store: %v\n", err)
 		}
 	}()
 
-	return leastReadVoca, nil
+	return uResult.Records[0], nil
 }
 
-func (s *store) getCSVStore() (*csvstore.CSVStore, error) {
-	if s.cs == nil {
+func (s *store) getBackend() (Backend, error) {
+	if s.backend == nil {
 		err := s.initialize()
 		if err != nil {
 			return nil, err
 		}
 	}
-	return s.cs, nil
+	return s.backend, nil
 }
 
 func (s *store) initialize() error {
-	tempDir := os.TempDir()
-	csvStoreFolderName := fmt.Sprintf("csv__voca--%s", time.Now().Format("2006-01-02"))
-	csvStoreFilepath := filepath.Join(tempDir, csvStoreFolderName)
-	if !checkIsFolderExists(csvStoreFilepath) {
-		cmd := exec.Command(
-			"git",
-			"clone",
-			"git@github.com:jiyeol-lee/csv__voca.git",
-			csvStoreFolderName,
-		)
-		cmd.Dir = tempDir
-		err := cmd.Run()
+	// a database changed on two machines cannot be merged, so git mode needs a text backend
+	if s.opts.StorageMode == StorageModeGit && s.opts.Backend == BackendSQLite {
+		return fmt.Errorf("the %s backend cannot be synced with git, use it with the local storage mode", BackendSQLite)
+	}
+
+	csvStoreFilepath, err := s.resolveStorePath()
+	if err != nil {
+		return err
+	}
+
+	// cloning and migrating write to the store, so they happen under its lock
+	s.storePath = csvStoreFilepath
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.opts.StorageMode == StorageModeGit {
+		err := s.prepareGitStore(csvStoreFilepath)
 		if err != nil {
-			return fmt.Errorf("error cloning CSV store repository: %w", err)
+			return err
 		}
 	}
-	cs, err := csvstore.NewCSVStore(
-		csvStoreFilepath,
-	)
+
+	backend, err := openBackend(s.opts.Backend, csvStoreFilepath)
+	if err != nil {
+		return fmt.Errorf("error opening %s backend: %w", s.opts.Backend, err)
+	}
+
+	version, err := migrate(backend, s.opts.TableName)
 	if err != nil {
-		return fmt.Errorf("error creating CSV store: %w", err)
+		return fmt.Errorf("error migrating vocabulary table: %w", err)
 	}
 
-	if !cs.CheckTableExists(vocabularyTableName) {
-		err = cs.CreateTable(
-			vocabularyTableName,
-			[]string{"id", "word", "read_count", "created_at", "updated_at"},
-		)
+	s.backend = backend
+	if version > 0 {
+		// commit the migration right away, so that even read-only commands leave a clean clone
+		err := s.syncStore("migrate", fmt.Sprintf("migrate %s to v%d", s.opts.TableName, version))
 		if err != nil {
-			return fmt.Errorf("error creating vocabulary table: %w", err)
+			log.Printf("error syncing store: %v\n", err)
 		}
 	}
-
-	s.cs = cs
-	s.storePath = csvStoreFilepath
+	if s.opts.StorageMode == StorageModeGit && s.hasPendingPush() {
+		s.logPendingStatus()
+	}
 	return nil
 }
+
+// resolveStorePath returns the directory of the store.
+// When no local path is configured, a persistent clone in the voca cache directory is used in git mode
+// and the voca data directory in local mode.
+func (s *store) resolveStorePath() (string, error) {
+	switch s.opts.StorageMode {
+	case StorageModeGit:
+		if s.opts.LocalPath != "" {
+			return s.opts.LocalPath, nil
+		}
+		return s.cachedClonePath()
+	case StorageModeLocal:
+		if s.opts.LocalPath != "" {
+			return s.opts.LocalPath, nil
+		}
+		dataDir, err := getDataDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting data directory: %w", err)
+		}
+		return filepath.Join(dataDir, "store"), nil
+	}
+	return "", fmt.Errorf("unsupported storage mode: %s", s.opts.StorageMode)
+}